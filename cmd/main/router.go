@@ -0,0 +1,72 @@
+package main
+
+import (
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"nationalparks-rest/pkg/config"
+	graphql2 "nationalparks-rest/pkg/graphql"
+	http2 "nationalparks-rest/pkg/http"
+	"net/http"
+)
+
+// newRouter registers every route the service serves.  changeFeed streams park changes, metricsHandler serves
+// /metrics unless it is nil, and with readReplicas reads go to the primary for a while after a client's own writes.
+// Every route under http2.APIPrefix must be described by the OpenAPI document, see router_test.go.
+func newRouter(cfg *config.Config, changeFeed http.Handler, metricsHandler http.Handler, readReplicas bool) (*mux.Router, error) {
+	router := mux.NewRouter()
+	var muxMiddleware = otelmux.Middleware("nationalparks-rest")
+	router.Use(muxMiddleware)
+	router.Use(http2.Metrics)
+	router.Use(http2.AccessLogRoute)
+	router.Use(http2.ServerTiming)
+	if readReplicas {
+		router.Use(http2.ReadYourWrites(cfg.DB.PrimaryAfterWrite))
+	}
+	// The change feed is a text/event-stream in every case, so it bypasses content negotiation.
+	router.Handle(http2.APIPrefix+"/nationalparks/changes", changeFeed).Methods(http.MethodGet).Name("nationalparkChanges")
+	api := router.PathPrefix(http2.APIPrefix).Subrouter()
+	api.Use(http2.Negotiate)
+	// Changing parks requires the write token, and the webhook endpoints, which show the URLs of every subscriber,
+	// the webhook token.
+	writes := http2.RequireToken(cfg.Auth.WriteToken, "WRITE_TOKEN")
+	webhooks := http2.RequireToken(cfg.Auth.WebhookToken, "WEBHOOK_TOKEN")
+
+	api.HandleFunc("/", http2.RouteHealthCheck).Methods(http.MethodGet)
+	api.HandleFunc("/health-check", http2.RouteHealthCheck).Methods("GET").Name("healthCheck")
+	api.HandleFunc("/openapi.json", http2.RouteOpenAPI).Methods(http.MethodGet).Name("openapi")
+	api.HandleFunc("/docs", http2.RouteDocs).Methods(http.MethodGet).Name("docs")
+	api.HandleFunc("/swagger-ui/{file}", http2.RouteSwaggerUIAsset).Methods(http.MethodGet).Name("swaggerUI")
+	api.HandleFunc("/nationalpark/{id:[0-9]+}", http2.RouteGetNationalParkById).Methods(http.MethodGet).Name("nationalpark")
+	api.Handle("/nationalpark/{id:[0-9]+}", writes(http.HandlerFunc(http2.RouteUpdateNationalPark))).Methods(http.MethodPut).Name("updateNationalpark")
+	api.Handle("/nationalpark/{id:[0-9]+}", writes(http.HandlerFunc(http2.RouteDeleteNationalPark))).Methods(http.MethodDelete).Name("deleteNationalpark")
+	api.HandleFunc("/nationalparks", http2.RouteGetNationalParks).Methods(http.MethodGet).Name("nationalparks")
+	api.Handle("/nationalparks", writes(http.HandlerFunc(http2.RouteCreateNationalPark))).Methods(http.MethodPost).Name("createNationalpark")
+	api.HandleFunc("/nationalparks/near", http2.RouteGetNationalParksNear).Methods(http.MethodGet).Name("nationalparksNear")
+	api.HandleFunc("/nationalparks/name/{parkname}", http2.RouteGetNationalParkByName).Methods(http.MethodGet).Name("nationalparkByName")
+	api.HandleFunc("/nationalparks/city/{city}", http2.RouteGetNationalParksByCity).Methods(http.MethodGet).Name("nationalparksByCity")
+	api.HandleFunc("/nationalparks/state/{stateabbr}", http2.RouteGetNationalParksByState).Methods(http.MethodGet).Name("nationalparksByState")
+	api.HandleFunc("/nationalparks/zipcode/{zipcode}", http2.RouteGetNationalParksByZipCode).Methods(http.MethodGet).Name("nationalparksByZipCode")
+	api.Handle("/webhooks", webhooks(http.HandlerFunc(http2.RouteGetWebhookSubscriptions))).Methods(http.MethodGet).Name("webhooks")
+	api.Handle("/webhooks", webhooks(http.HandlerFunc(http2.RouteCreateWebhookSubscription))).Methods(http.MethodPost).Name("createWebhook")
+	api.Handle("/webhooks/{id:[0-9]+}", webhooks(http.HandlerFunc(http2.RouteGetWebhookSubscription))).Methods(http.MethodGet).Name("webhook")
+	api.Handle("/webhooks/{id:[0-9]+}", webhooks(http.HandlerFunc(http2.RouteDeleteWebhookSubscription))).Methods(http.MethodDelete).Name("deleteWebhook")
+	api.Handle("/webhooks/dead-letters", webhooks(http.HandlerFunc(http2.RouteGetDeadWebhookDeliveries))).Methods(http.MethodGet).Name("webhookDeadLetters")
+	api.Handle("/webhooks/dead-letters/{id:[0-9]+}/retry", webhooks(http.HandlerFunc(http2.RouteRetryWebhookDelivery))).Methods(http.MethodPost).Name("retryWebhookDelivery")
+
+	// GraphQL has its own schema and versioning, so it lives outside of the REST API prefix.
+	graphqlHandler, err := graphql2.NewHandler(cfg.GraphQL.MaxDepth, cfg.GraphQL.MaxComplexity)
+	if err != nil {
+		return nil, err
+	}
+	router.Handle("/graphql", graphqlHandler).Methods(http.MethodGet, http.MethodPost).Name("graphql")
+	// Kubernetes probes, see the deployment.
+	router.HandleFunc("/healthz", http2.RouteLiveness).Methods(http.MethodGet).Name("liveness")
+	router.HandleFunc("/readyz", http2.RouteReadiness).Methods(http.MethodGet).Name("readiness")
+	// Admin endpoints are not part of the public API either.
+	router.HandleFunc("/admin/log-level", http2.RouteGetLogLevel).Methods(http.MethodGet).Name("logLevel")
+	router.HandleFunc("/admin/log-level", http2.RouteSetLogLevel).Methods(http.MethodPut).Name("setLogLevel")
+	if metricsHandler != nil {
+		router.Handle("/metrics", metricsHandler).Methods(http.MethodGet).Name("metrics")
+	}
+	return router, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"nationalparks-rest/pkg/config"
+	http2 "nationalparks-rest/pkg/http"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Routes that the OpenAPI document deliberately leaves out.
+var undocumentedRoutes = map[string]string{
+	"GET /graphql":                  "GraphQL has its own schema",
+	"POST /graphql":                 "GraphQL has its own schema",
+	"GET /healthz":                  "Kubernetes liveness probe",
+	"GET /readyz":                   "Kubernetes readiness probe",
+	"GET /metrics":                  "Prometheus scrape endpoint",
+	"GET /admin/log-level":          "admin endpoint, not part of the public API",
+	"PUT /admin/log-level":          "admin endpoint, not part of the public API",
+	"GET /api/v1/swagger-ui/{file}": "assets of the docs page",
+}
+
+var pathVariablePattern = regexp.MustCompile(`\{([^}:]+):[^}]+\}`)
+
+// openAPIOperations returns the operations of the served OpenAPI document as "METHOD /path" under http2.APIPrefix.
+func openAPIOperations(t *testing.T) map[string]bool {
+	w := httptest.NewRecorder()
+	http2.RouteOpenAPI(w, httptest.NewRequest(http.MethodGet, http2.APIPrefix+"/openapi.json", nil))
+	var document struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+	operations := make(map[string]bool)
+	for path, methods := range document.Paths {
+		for method := range methods {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options":
+				operations[strings.ToUpper(method)+" "+strings.TrimSuffix(http2.APIPrefix+path, "/")] = true
+			}
+		}
+	}
+	return operations
+}
+
+// routes returns the routes of router as "METHOD /path", with plain {name} parameters where the router has
+// {name:pattern}, as in the OpenAPI document.
+func routes(t *testing.T, router *mux.Router) []string {
+	var routes []string
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if route.GetHandler() == nil {
+			// Subrouter prefixes only group the routes below them.
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		path := strings.TrimSuffix(pathVariablePattern.ReplaceAllString(template, "{$1}"), "/")
+		methods, err := route.GetMethods()
+		if err != nil {
+			t.Errorf("%s is registered without methods", path)
+		}
+		for _, method := range methods {
+			routes = append(routes, method+" "+path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return routes
+}
+
+func TestRoutesAreDocumented(t *testing.T) {
+	cfg := config.Default()
+	changeFeed := http2.NewChangeFeed(time.Second, 5*time.Second, time.Second)
+	router, err := newRouter(cfg, changeFeed, http.NotFoundHandler(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operations := openAPIOperations(t)
+	registered := make(map[string]bool)
+	var missing []string
+	for _, route := range routes(t, router) {
+		registered[route] = true
+		if _, ok := undocumentedRoutes[route]; !ok && !operations[route] {
+			missing = append(missing, route)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		t.Errorf("routes missing from the OpenAPI document: %s", strings.Join(missing, ", "))
+	}
+
+	var stale []string
+	for operation := range operations {
+		if !registered[operation] {
+			stale = append(stale, operation)
+		}
+	}
+	for route := range undocumentedRoutes {
+		if !registered[route] {
+			stale = append(stale, route)
+		}
+	}
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		t.Errorf("operations without a route: %s", strings.Join(stale, ", "))
+	}
+}
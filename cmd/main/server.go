@@ -3,20 +3,32 @@ package main
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"flag"
 	"fmt"
-	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 	log "github.com/sirupsen/logrus"
-	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	nethttp2 "golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	grpccredentials "google.golang.org/grpc/credentials"
 	"nationalparks-rest/pkg"
+	"nationalparks-rest/pkg/config"
 	"nationalparks-rest/pkg/db"
+	graphql2 "nationalparks-rest/pkg/graphql"
+	grpc2 "nationalparks-rest/pkg/grpc"
 	http2 "nationalparks-rest/pkg/http"
+	"nationalparks-rest/pkg/webhook"
+	"os/signal"
 	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 
+	"net"
 	"net/http"
 	"os"
 
@@ -24,120 +36,296 @@ import (
 )
 
 var dtb *sql.DB
-var ctx context.Context
+var replicaDBs []*sql.DB
 
-var dbHost string
-var dbPort int
-var httpHost string
-var httpPort int
+// Timeouts so the server never waits forever...
+const httpReadTimeout = 15 * time.Second
+const httpWriteTimeout = 15 * time.Second
+
+// How long the spans and metrics still buffered at shutdown may take to export.
+const telemetryFlushTimeout = 5 * time.Second
 
 func main() {
 	var err error
 
+	args := os.Args[1:]
+	printConfig := len(args) >= 2 && args[0] == "config" && args[1] == "print"
+	if printConfig {
+		args = args[2:]
+	}
+	cfg, err := config.Load(args)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		// Before logging is configured, and meant to be read by whoever started the service.
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	if printConfig {
+		if err = cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+
 	log.Printf("National Parks REST API Service")
 
-	processEnvVariables()
+	// The configuration has been validated, so this cannot fail.
+	pkg.ConfigureLogging(cfg.Log.Level, cfg.Log.Format)
 
 	// Initialize our OpenTelemetry tracing setup
 	cleanup := pkg.InitOTEL("nationalparks-rest", "development")
-	defer cleanup(context.Background())
 
-	log.Printf("Using MySQL instance at %s:%d", dbHost, dbPort)
+	// Initialize the metrics, served at /metrics for Prometheus and pushed with OTLP depending on the environment
+	metricsHandler, metricsCleanup := pkg.InitMetrics("nationalparks-rest", "development")
 
-	// Initialize the database connection
-	var driverName string
-	driverName, err = otelsql.Register("mysql", semconv.DBSystemMySQL.Value.AsString())
+	log.Printf("Using MySQL instance at %s:%d", cfg.DB.Host, cfg.DB.Port)
 
-	var connString = db.GetConnectionString("nationalparks_user", "nationalparks_user", dbHost, dbPort, "nationalparks_db")
-	dtb, err = sql.Open(driverName, connString)
+	// Initialize the database connection.  The credentials are read again for every new connection, so that rotated
+	// secrets are picked up without a restart.
+	driver := otelsql.WrapDriver(mysql.MySQLDriver{}, semconv.DBSystemMySQL.Value.AsString())
+	credentials := db.FileCredentials(cfg.DB.User, cfg.DB.UserFile, cfg.DB.Password, cfg.DB.PasswordFile)
+	connector, err := db.NewConnector(driver, credentials, cfg.DB.Host, cfg.DB.Port, cfg.DB.Name, cfg.DB.Params)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to connect to database: %+v\n", err)
 		os.Exit(-1)
 	}
-	defer dtb.Close()
+	dtb = openPool(connector, cfg.DB)
+
+	// Read replicas are reached with the credentials, database name and options of the primary.
+	for _, address := range cfg.DB.Replicas {
+		host, port, _ := config.SplitHostPort(address, cfg.DB.Port)
+		replicaConnector, err := db.NewConnector(driver, credentials, host, port, cfg.DB.Name, cfg.DB.Params)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to connect to database replica %s: %+v\n", address, err)
+			os.Exit(-1)
+		}
+		replica := openPool(replicaConnector, cfg.DB)
+		replicaDBs = append(replicaDBs, replica)
+		db.AddReplica(net.JoinHostPort(host, strconv.Itoa(port)), replica)
+	}
+	if len(replicaDBs) > 0 {
+		policy, _ := db.ParseReplicaPolicy(cfg.DB.ReplicaPolicy)
+		db.SetReplicaPolicy(policy)
+		log.Printf("Reading from %d replicas, %s", len(replicaDBs), policy)
+	}
+	db.SetQueryTimeout(cfg.DB.QueryTimeout)
+	db.SetReadRetries(cfg.DB.ReadRetries)
+	db.SetCircuitBreaker(cfg.DB.BreakerFailures, cfg.DB.BreakerCooldown)
+
+	// The database may still be starting, retry for a while before giving up.
+	if err = db.WaitForDB(context.Background(), dtb, cfg.DB.ConnectTimeout); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err = db.CheckServerVersion(context.Background(), dtb); err != nil {
+		log.Fatalf("%v", err)
+	}
+	db.ObservePoolStats(dtb)
 	http2.SetDB(dtb)
+	grpc2.SetDB(dtb)
+	graphql2.SetDB(dtb)
+
+	// The read-only endpoints work without the tables created by migrations, so a database user that may not create
+	// tables only loses the mutation endpoints and the change feed.
+	if err = db.Migrate(context.Background(), dtb); err != nil {
+		log.Errorf("Failed to migrate the database, park changes will fail: %v", err)
+	}
+
+	// The change feed, the webhook dispatcher and the replica health checks run in the background until shutdown.
+	background, stopBackground := context.WithCancel(context.Background())
+	var backgroundDone sync.WaitGroup
+	backgroundDone.Add(2)
+	if len(replicaDBs) > 0 {
+		backgroundDone.Add(1)
+		go func() {
+			defer backgroundDone.Done()
+			db.CheckReplicas(background, cfg.DB.ReplicaCheckInterval)
+		}()
+	}
+
+	// Change streams outlive the write timeout, which applies to each of their writes instead.
+	changeFeed := http2.NewChangeFeed(time.Second, 5*time.Second, httpWriteTimeout)
+	http2.SetChangeFeed(changeFeed)
+	go func() {
+		defer backgroundDone.Done()
+		changeFeed.Run(background)
+	}()
+
+	// Deliver the webhook outbox in the background.
+	webhookDispatcher := webhook.NewDispatcher(dtb)
+	http2.SetWebhookDispatcher(webhookDispatcher)
+	go func() {
+		defer backgroundDone.Done()
+		webhookDispatcher.Run(background)
+	}()
 
 	// Initialize the HTTP Router
-	router := mux.NewRouter()
-	var muxMiddleware = otelmux.Middleware("nationalparks-rest")
-	router.Use(muxMiddleware)
-	api := router.PathPrefix("/api/v1").Subrouter()
-
-	api.HandleFunc("/", http2.RouteHealthCheck).Methods(http.MethodGet)
-	api.HandleFunc("/health-check", http2.RouteHealthCheck).Methods("GET")
-	api.HandleFunc("/nationalpark/{id:[0-9]+}", http2.RouteGetNationalParkById).Methods(http.MethodGet)
-	api.HandleFunc("/nationalparks", http2.RouteGetNationalParks).Methods(http.MethodGet)
-	api.HandleFunc("/nationalparks/name/{parkname}", http2.RouteGetNationalParkByName).Methods(http.MethodGet)
-	api.HandleFunc("/nationalparks/city/{city}", http2.RouteGetNationalParksByCity).Methods(http.MethodGet)
-	api.HandleFunc("/nationalparks/state/{stateabbr}", http2.RouteGetNationalParksByState).Methods(http.MethodGet)
-	api.HandleFunc("/nationalparks/zipcode/{zipcode}", http2.RouteGetNationalParksByZipCode).Methods(http.MethodGet)
-
-	handler := cors.Default().Handler(router)
+	http2.SetAdminToken(cfg.Admin.Token)
+	router, err := newRouter(cfg, changeFeed, metricsHandler, len(replicaDBs) > 0)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	http2.SetRouter(router)
+
+	// Only believe X-Forwarded-For when it was set by one of our own proxies.
+	proxies, err := http2.ParseTrustedProxies(cfg.HTTP.TrustedProxies)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	// Only scripts from the configured origins may call the API from a browser.  Without any, no CORS headers are sent
+	// at all, rs/cors would allow every origin.
+	var handler http.Handler = router
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		handler = cors.New(cors.Options{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			ExposedHeaders:   cfg.CORS.ExposedHeaders,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           int((cfg.CORS.MaxAge + time.Second - 1) / time.Second),
+		}).Handler(handler)
+	}
+	handler = http2.AccessLog(proxies)(handler)
+
+	// Within the cluster, clients that know the server speaks HTTP/2 may use it without TLS.
+	if cfg.HTTP.H2C {
+		handler = h2c.NewHandler(handler, &nethttp2.Server{})
+	}
 
 	// Setup HTTP server
-	var httpServer = httpHost + ":" + strconv.Itoa(httpPort)
+	var httpServer = cfg.HTTP.Host + ":" + strconv.Itoa(cfg.HTTP.Port)
 	server := &http.Server{
 		//Handler: router,
-		Handler: handler,
-		Addr:    httpServer,
-		// timeouts so the server never waits forever...
-		WriteTimeout: 15 * time.Second,
-		ReadTimeout:  15 * time.Second,
+		Handler:      handler,
+		Addr:         httpServer,
+		WriteTimeout: httpWriteTimeout,
+		ReadTimeout:  httpReadTimeout,
 	}
 
+	// With a certificate, both the HTTP and the gRPC port serve TLS only.
+	var grpcOptions []grpc.ServerOption
+	if cfg.HTTP.TLSCertFile != "" {
+		certs, err := http2.NewCertReloader(cfg.HTTP.TLSCertFile, cfg.HTTP.TLSKeyFile, cfg.HTTP.TLSClientCAFile)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		minVersion, _ := http2.ParseTLSVersion(cfg.HTTP.TLSMinVersion)
+		requireClientCert := cfg.HTTP.TLSClientAuth == "require"
+		server.TLSConfig = certs.TLSConfig(minVersion, requireClientCert, "h2", "http/1.1")
+		grpcOptions = append(grpcOptions, grpc.Creds(grpccredentials.NewTLS(certs.TLSConfig(minVersion, requireClientCert, "h2"))))
+	}
+
+	// Setup the gRPC server on its own port, sharing the database connection with the REST API
+	var grpcServerAddr = cfg.HTTP.Host + ":" + strconv.Itoa(cfg.GRPC.Port)
+	grpcListener, err := net.Listen("tcp", grpcServerAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen for gRPC connections: %v", err)
+	}
+	grpcServer := grpc2.NewServer(grpcOptions...)
+	go func() {
+		log.Printf("gRPC server started at %s", grpcServerAddr)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatalf("gRPC server failed: %v", err)
+		}
+	}()
+
 	// Start accepting connections...
-	log.Printf("Server started at %s", httpServer)
-	log.Fatal(server.ListenAndServe())
+	go func() {
+		var err error
+		if server.TLSConfig != nil {
+			log.Printf("Server started at %s with TLS %s or later", httpServer, cfg.HTTP.TLSMinVersion)
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			log.Printf("Server started at %s", httpServer)
+			err = server.ListenAndServe()
+		}
+		if err != http.ErrServerClosed {
+			log.Fatalf("HTTP server failed: %v", err)
+		}
+	}()
+
+	// Run until Docker or Kubernetes sends SIGTERM, or Ctrl-C is pressed.  A second signal kills the process.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	sig := <-signals
+	signal.Stop(signals)
+
+	shutdown(sig, cfg.Shutdown, server, grpcServer, stopBackground, &backgroundDone, cleanup, metricsCleanup)
 }
 
-func init() {
-	// Log as JSON instead of the default ASCII formatter.
-	log.SetFormatter(&log.JSONFormatter{
-		FieldMap: log.FieldMap{
-			log.FieldKeyTime:  "timestamp",
-			log.FieldKeyLevel: "severity",
-			log.FieldKeyMsg:   "message",
-		},
-		TimestampFormat: time.RFC3339Nano,
-	})
+// shutdown stops the service without failing the requests in flight.  Readiness fails first, so that load balancers
+// stop sending new requests during the shutdown delay.  Then the servers stop accepting connections and have until
+// the shutdown timeout to finish the requests they are serving, while the change streams are ended and the background
+// work is stopped.  The spans and metrics recorded up to the end are exported before the database is closed.
+func shutdown(sig os.Signal, timeouts config.ShutdownConfig, server *http.Server, grpcServer *grpc.Server, stopBackground context.CancelFunc,
+	backgroundDone *sync.WaitGroup, cleanup func(context.Context) error, metricsCleanup func(context.Context) error) {
+	log.Printf("Received %s, shutting down within %s", sig, timeouts.Delay+timeouts.Timeout)
+	http2.SetShuttingDown()
+	time.Sleep(timeouts.Delay)
 
-	// Output to stdout instead of the default stderr
-	// Can be any io.Writer, see below for File example
-	log.SetOutput(os.Stdout)
+	stopBackground()
 
-	// Only log the warning severity or above.
-	log.SetLevel(log.DebugLevel)
-}
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Timeout)
+	defer cancel()
 
-func processEnvVariables() {
-	var exists bool
-	var val string
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
 
-	dbHost = os.Getenv("DBHOST")
-	if val, exists = os.LookupEnv("DBPORT"); exists == true {
-		dbPort, _ = strconv.Atoi(val)
-	} else {
-		dbPort = 3306
+	if err := server.Shutdown(ctx); err != nil {
+		log.Warnf("Requests still in flight after %s, closing their connections: %v", timeouts.Timeout, err)
+		server.Close()
+	}
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		log.Warnf("gRPC calls still in flight after %s, closing their connections", timeouts.Timeout)
+		grpcServer.Stop()
 	}
-	httpHost = os.Getenv("HTTPHOST")
-	if val, exists = os.LookupEnv("HTTPPORT"); exists == true {
-		httpPort, _ = strconv.Atoi(val)
-	} else {
-		httpPort = 8080
+	backgroundDone.Wait()
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), telemetryFlushTimeout)
+	defer cancelFlush()
+	if err := cleanup(flushCtx); err != nil {
+		log.Warnf("Failed to export the remaining spans: %v", err)
 	}
+	if err := metricsCleanup(flushCtx); err != nil {
+		log.Warnf("Failed to export the remaining metrics: %v", err)
+	}
+
+	if err := dtb.Close(); err != nil {
+		log.Warnf("Failed to close the database: %v", err)
+	}
+	for _, replica := range replicaDBs {
+		if err := replica.Close(); err != nil {
+			log.Warnf("Failed to close a database replica: %v", err)
+		}
+	}
+	log.Printf("Shutdown complete")
 }
 
-//func processCmdLine() {
-//	flag.StringVar(&dbHost, "dbhost", "", "Hostname or IP Address of the MySQL server.")
-//	flag.IntVar(&dbPort, "dbport", 3306, "Port number MySQL server is listening on.")
-//	flag.StringVar(&httpHost,"httphost", "127.0.0.1", "TCP address for the server to listen.")
-//	flag.IntVar(&httpPort,"httpport", 8080, "Port number the HTTP server should accept connections on.")
-//	flag.Parse()
-//
-//	if len(dbHost) == 0 {
-//		fmt.Fprintf(os.Stderr, "ERROR: dbhost not specified.\n")
-//		fmt.Fprintf(os.Stderr, "Usage: %s <options>, where <options> are:\n", os.Args[0])
-//		flag.PrintDefaults()
-//		os.Exit(-1)
-//	}
-//}
+// openPool opens a connection pool to the database reached through connector.
+func openPool(connector driver.Connector, cfg config.DBConfig) *sql.DB {
+	pool := sql.OpenDB(connector)
+	pool.SetMaxOpenConns(cfg.MaxOpenConns)
+	pool.SetMaxIdleConns(cfg.MaxIdleConns)
+	pool.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	pool.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return pool
+}
+
+func init() {
+	// Log as JSON at the info level until the configured level and format are applied.
+	pkg.ConfigureLogging("info", "json")
+
+	// Output to stdout instead of the default stderr
+	// Can be any io.Writer, see below for File example
+	log.SetOutput(os.Stdout)
+
+	// Add the trace and span ids to lines logged with a request context.
+	log.AddHook(pkg.TraceHook{})
+}
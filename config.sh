@@ -5,6 +5,29 @@
 #export SPLUNK_ACCESS_TOKEN=
 #export SPLUNK_REALM=
 
+# To send traces somewhere else, pick an exporter (otlp, jaeger, console or none) and configure it with the standard
+# OpenTelemetry variables.  Without any of these, or the Splunk credentials above, traces are not exported.
+#export OTEL_TRACES_EXPORTER=otlp
+#export OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4317
+#export OTEL_EXPORTER_OTLP_PROTOCOL=grpc
+
+# Trace context formats accepted and sent (tracecontext, baggage, b3, b3multi, jaeger), and the baggage members copied
+# to span attributes.
+#export OTEL_PROPAGATORS=tracecontext,baggage
+#export BAGGAGE_SPAN_ATTRIBUTES=tenant,client.app
+
+# Sample 10% of new traces, following the caller's decision for the others.  See the README for the other samplers and
+# for suppressing noisy spans with TRACES_SUPPRESSED_SPANS.
+#export OTEL_TRACES_SAMPLER=parentbased_traceidratio
+#export OTEL_TRACES_SAMPLER_ARG=0.1
+
+# Metrics are served at /metrics for Prometheus, and pushed to the OTLP endpoint above if it is set.  Pick the exporters
+# explicitly with a comma separated list of prometheus, otlp or none.
+#export OTEL_METRICS_EXPORTER=prometheus,otlp
+
+# Settings may also be read from a YAML file, see the README.  The variables below override the file.
+#export CONFIG_FILE=nationalparks-rest.yaml
+
 # BACKEND_URL represents the URL where this service will be accessible from.
 # It should be in the form (http|https)://(hostname|ip address):(port number).
 #export BACKEND_URL=http://localhost:8080
@@ -17,8 +40,95 @@ export HTTPHOST=0.0.0.0
 # The network port this service should listen on.  Default is 8080.
 export HTTPPORT=8080
 
+# The network port the gRPC API should listen on (on the same interface as HTTPHOST).  Default is 9090.
+export GRPCPORT=9090
+
+# Proxies and load balancers whose X-Forwarded-For header is trusted for the client address in the request logs, as a
+# comma separated list of IP addresses and CIDR ranges.
+#export TRUSTED_PROXIES=10.0.0.0/8
+
+# Serve HTTPS, and gRPC over TLS, with this PEM certificate chain and key, reloaded when they change, to clients of at
+# least TLS 1.2 by default.  With a client CA, clients must present a certificate signed by it (require, the default)
+# or have the one they present verified (verify_if_given).
+#export TLS_CERT_FILE=/etc/nationalparks-tls/tls.crt
+#export TLS_KEY_FILE=/etc/nationalparks-tls/tls.key
+#export TLS_MIN_VERSION=1.2
+#export TLS_CLIENT_CA_FILE=/etc/nationalparks-tls/ca.crt
+#export TLS_CLIENT_AUTH=require
+
+# PEM certificate chain and key that kubernetes/create-secrets.sh stores in the nationalparks-tls secret, which the
+# deployment serves HTTPS with.
+#export TLS_CRT=tls.crt
+#export TLS_KEY=tls.key
+
+# Without TLS, also serve HTTP/2 to clients that know the service speaks it (h2c), such as other services in the cluster.
+#export H2C=true
+
+# Comma separated origins whose browser scripts may call the service, none by default.  The methods, request headers
+# and exposed response headers default to those the API uses, see the README.
+#export CORS_ALLOWED_ORIGINS=https://parks.example.com,https://*.example.com
+#export CORS_ALLOWED_METHODS=GET,HEAD,POST,PUT,DELETE
+#export CORS_ALLOWED_HEADERS=Accept,Authorization,Content-Type,Last-Event-ID,traceparent,tracestate,baggage
+#export CORS_EXPOSED_HEADERS=Server-Timing,traceresponse,Location,Retry-After
+#export CORS_ALLOW_CREDENTIALS=false
+#export CORS_MAX_AGE=10m
+
+# Log level (error, warn, info, debug or trace) and format (json or text).  Defaults are info and json.
+#export LOG_LEVEL=info
+#export LOG_FORMAT=json
+
+# Bearer token required to change the log level with PUT /admin/log-level.  Disabled when unset.
+#export ADMIN_TOKEN=
+
+# Bearer token required to create, update and delete parks.  Changing parks is disabled when unset.
+#export WRITE_TOKEN=
+
+# Bearer token required to manage webhook subscriptions and dead letters.  The webhook endpoints are disabled when unset.
+#export WEBHOOK_TOKEN=
+
 # The IP Address the MySQL instance is hosted at.
 export DBHOST=192.168.3.230
 
 # The port number the MySQL instance is listening on.
-export DBPORT=3306
\ No newline at end of file
+export DBPORT=3306
+
+# The MySQL user and database, nationalparks_user and nationalparks_db by default, and the password, which is required.
+# The nationalparks-mysql project creates nationalparks_user with the password nationalparks_user.
+#export DBUSER=nationalparks_user
+export DBPASSWORD=
+#export DBNAME=nationalparks_db
+
+# Alternatively, read the user and password from files, again for every new connection so they can be rotated.
+#export DBUSER_FILE=/var/run/secrets/nationalparks-db/username
+#export DBPASSWORD_FILE=/var/run/secrets/nationalparks-db/password
+
+# MySQL driver options, see https://github.com/go-sql-driver/mysql#parameters
+#export DBPARAMS="tls=preferred&timeout=5s"
+
+# How long to keep retrying the database at startup before giving up, as a Go duration.  Default is 1m.
+#export DB_CONNECT_TIMEOUT=1m
+
+# Database connection pool.  Defaults are 20 open and 10 idle connections, replaced after 5m and closed when idle for 1m.
+#export DB_MAX_OPEN_CONNS=20
+#export DB_MAX_IDLE_CONNS=10
+#export DB_CONN_MAX_LIFETIME=5m
+#export DB_CONN_MAX_IDLE_TIME=1m
+
+# How long a query may take (default 5s), how often reads failing with a broken connection or deadlock are retried
+# (default 2), and after how many failures in a row requests fail fast with 503 (default 5), for how long (default 10s).
+#export DB_QUERY_TIMEOUT=5s
+#export DB_READ_RETRIES=2
+#export DB_BREAKER_FAILURES=5
+#export DB_BREAKER_COOLDOWN=10s
+
+# Comma separated host[:port] of read replicas to spread reads over, round_robin (default) or least_connections.  The
+# replicas are pinged every 5s by default, and the reads of a client go to the primary for 5s after it wrote.
+#export DBREPLICAS=192.168.3.231,192.168.3.232
+#export DB_REPLICA_POLICY=round_robin
+#export DB_REPLICA_CHECK_INTERVAL=5s
+#export DB_PRIMARY_AFTER_WRITE=5s
+
+# On SIGTERM, how long to keep serving while readiness fails so load balancers stop sending requests (default 0s), and
+# then how long the requests in flight have to finish (default 20s).
+#export SHUTDOWN_DELAY=5s
+#export SHUTDOWN_TIMEOUT=20s
\ No newline at end of file
@@ -25,3 +25,38 @@ fi
 kubectl create secret generic --namespace nationalparks splunk-access \
   --from-literal=token="${SPLUNK_ACCESS_TOKEN}" \
   --from-literal=realm="${SPLUNK_REALM}"
+
+
+# Creates a secret named `nationalparks-db` in the `nationalparks` namespace holding the MySQL credentials, which the
+# deployment mounts as files.  Update it with the same command and --dry-run=client -o yaml | kubectl apply -f - to
+# rotate the credentials, running pods pick them up for their next connections.
+
+if [[ -z "${DBPASSWORD}" ]]; then
+  echo "Environment variable DBPASSWORD not defined."
+  exit -1
+fi
+
+kubectl create secret generic --namespace nationalparks nationalparks-db \
+  --from-literal=username="${DBUSER:-nationalparks_user}" \
+  --from-literal=password="${DBPASSWORD}"
+
+
+# Creates a secret named `nationalparks-api` in the `nationalparks` namespace holding the bearer tokens required to
+# change parks and to manage webhooks.  The endpoints whose token is not set stay disabled.
+
+if [[ -n "${WRITE_TOKEN}" || -n "${WEBHOOK_TOKEN}" ]]; then
+  kubectl create secret generic --namespace nationalparks nationalparks-api \
+    ${WRITE_TOKEN:+--from-literal=write-token="${WRITE_TOKEN}"} \
+    ${WEBHOOK_TOKEN:+--from-literal=webhook-token="${WEBHOOK_TOKEN}"}
+fi
+
+
+# Creates a TLS secret named `nationalparks-tls` in the `nationalparks` namespace holding the certificate the service
+# serves HTTPS with, from the PEM files named by TLS_CRT and TLS_KEY.  Leave them unset when cert-manager, or anything
+# else, issues the secret.
+
+if [[ -n "${TLS_CRT}" && -n "${TLS_KEY}" ]]; then
+  kubectl create secret tls --namespace nationalparks nationalparks-tls \
+    --cert="${TLS_CRT}" \
+    --key="${TLS_KEY}"
+fi
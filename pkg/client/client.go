@@ -0,0 +1,324 @@
+// Package client is a typed Go client for the National Parks REST API.
+//
+//	c, err := client.New("http://localhost:8080")
+//	park, err := c.GetPark(ctx, 1)
+//	if errors.Is(err, client.ErrNotFound) {
+//		...
+//	}
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"math"
+	"math/rand"
+	"nationalparks-rest/pkg"
+	"nationalparks-rest/pkg/db"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const apiPrefix = "/api/v1"
+
+// Client calls the National Parks REST API.  It is safe for concurrent use.
+type Client struct {
+	baseURL        *url.URL
+	httpClient     *http.Client
+	propagator     propagation.TextMapPropagator
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// Option customizes a Client created by New.
+type Option func(c *Client)
+
+// WithHTTPClient replaces the http.Client used for requests, e.g. to set timeouts or a custom transport.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how many times a request failing with a network error or a 500, 502, 503 or 504 status is retried,
+// and the backoff before the first retry.  The backoff doubles with every attempt up to maxBackoff.  A 503 with a
+// Retry-After header is retried after the time it asks for instead, or not at all if that is longer than maxBackoff.
+// Zero retries disables retrying.
+func WithRetries(maxRetries int, initialBackoff time.Duration, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.initialBackoff = initialBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithPropagator replaces the W3C Trace Context propagator used to pass the caller's trace to the server.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *Client) {
+		c.propagator = propagator
+	}
+}
+
+// New returns a Client for the service at baseURL, for example "http://localhost:8080".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL %q: %w", baseURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme and host are required", baseURL)
+	}
+
+	c := &Client{
+		baseURL:        u,
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+		propagator:     propagation.TraceContext{},
+		maxRetries:     3,
+		initialBackoff: 100 * time.Millisecond,
+		maxBackoff:     2 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Page selects a window of a park list.  A zero Count uses the server's default page size.
+type Page struct {
+	Start int
+	Count int
+}
+
+func (p Page) apply(query url.Values) {
+	if p.Start > 0 {
+		query.Set("start", strconv.Itoa(p.Start))
+	}
+	if p.Count > 0 {
+		query.Set("count", strconv.Itoa(p.Count))
+	}
+}
+
+// ListOptions filters ListParks.  Empty fields match every park; City, State and ZipCode may contain SQL LIKE
+// wildcards.
+type ListOptions struct {
+	City    string
+	State   string
+	ZipCode string
+	Page
+}
+
+// HealthCheck returns nil when the service reports itself as up.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	var message string
+	return c.get(ctx, "HealthCheck", "/health-check", nil, &message)
+}
+
+// GetPark returns the park with the given id.
+func (c *Client) GetPark(ctx context.Context, id int) (db.NationalPark, error) {
+	var np db.NationalPark
+	err := c.get(ctx, "GetPark", "/nationalpark/"+strconv.Itoa(id), nil, &np)
+	return np, err
+}
+
+// GetParkByName returns the park with exactly the given name.
+func (c *Client) GetParkByName(ctx context.Context, name string) (db.NationalPark, error) {
+	var np db.NationalPark
+	err := c.get(ctx, "GetParkByName", "/nationalparks/name/"+url.PathEscape(name), nil, &np)
+	return np, err
+}
+
+// ListParks returns the parks matching opts.
+func (c *Client) ListParks(ctx context.Context, opts ListOptions) ([]db.NationalPark, error) {
+	query := url.Values{}
+	if opts.City != "" {
+		query.Set("city", opts.City)
+	}
+	if opts.State != "" {
+		query.Set("state", opts.State)
+	}
+	if opts.ZipCode != "" {
+		query.Set("zipcode", opts.ZipCode)
+	}
+	opts.Page.apply(query)
+
+	var nps []db.NationalPark
+	err := c.get(ctx, "ListParks", "/nationalparks", query, &nps)
+	return nps, err
+}
+
+// ListParksByCity returns the parks in the given city.
+func (c *Client) ListParksByCity(ctx context.Context, city string, page Page) ([]db.NationalPark, error) {
+	return c.list(ctx, "ListParksByCity", "/nationalparks/city/"+url.PathEscape(city), page)
+}
+
+// ListParksByState returns the parks in the state with the given two letter abbreviation.
+func (c *Client) ListParksByState(ctx context.Context, state string, page Page) ([]db.NationalPark, error) {
+	return c.list(ctx, "ListParksByState", "/nationalparks/state/"+url.PathEscape(state), page)
+}
+
+// ListParksByZipCode returns the parks in the given zip code.
+func (c *Client) ListParksByZipCode(ctx context.Context, zipCode int, page Page) ([]db.NationalPark, error) {
+	return c.list(ctx, "ListParksByZipCode", "/nationalparks/zipcode/"+strconv.Itoa(zipCode), page)
+}
+
+// SearchNear returns the parks within radiusMiles of the given coordinate, closest first.  A zero radius uses the
+// server's default.
+func (c *Client) SearchNear(ctx context.Context, latitude float64, longitude float64, radiusMiles float64, page Page) ([]db.NationalPark, error) {
+	query := url.Values{}
+	query.Set("lat", strconv.FormatFloat(latitude, 'f', -1, 64))
+	query.Set("lon", strconv.FormatFloat(longitude, 'f', -1, 64))
+	if radiusMiles > 0 {
+		query.Set("radius", strconv.FormatFloat(radiusMiles, 'f', -1, 64))
+	}
+	page.apply(query)
+
+	var nps []db.NationalPark
+	err := c.get(ctx, "SearchNear", "/nationalparks/near", query, &nps)
+	return nps, err
+}
+
+func (c *Client) list(ctx context.Context, operation string, path string, page Page) ([]db.NationalPark, error) {
+	query := url.Values{}
+	page.apply(query)
+
+	var nps []db.NationalPark
+	err := c.get(ctx, operation, path, query, &nps)
+	return nps, err
+}
+
+// get performs a GET of the already escaped path relative to the API prefix and decodes the JSON response into out,
+// retrying transient failures.  operation names the client span.
+func (c *Client) get(ctx context.Context, operation string, path string, query url.Values, out interface{}) error {
+	u := c.baseURL.String() + apiPrefix + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	span.SetAttributes(semconv.HTTPMethodKey.String(http.MethodGet), semconv.HTTPURLKey.String(u))
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		var wait time.Duration
+		if retry, wait, err = c.do(ctx, u, out); !retry || attempt >= c.maxRetries {
+			break
+		}
+		if wait == 0 {
+			wait = c.backoff(attempt)
+		}
+
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt+1), attribute.String("error", err.Error())))
+		if err = sleep(ctx, wait); err != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// do performs a single attempt and reports whether a failure is worth retrying, and how long the server asked to wait
+// before doing so, zero for the usual backoff.
+func (c *Client) do(ctx context.Context, u string, out interface{}) (bool, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	c.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// Network errors are retried unless the caller gave up.
+		return ctx.Err() == nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	trace.SpanFromContext(ctx).SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ctx.Err() == nil, 0, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := newAPIError(resp.StatusCode, body)
+		apiErr.RetryAfter = retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		switch resp.StatusCode {
+		case http.StatusServiceUnavailable:
+			// The server is refusing requests for a while, retrying before then would fail again.
+			return apiErr.RetryAfter <= c.maxBackoff, apiErr.RetryAfter, apiErr
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+			return true, 0, apiErr
+		}
+		// Other statuses, such as 501 Not Implemented, would be the same on every attempt.
+		return false, 0, apiErr
+	}
+	if err = json.Unmarshal(body, out); err != nil {
+		return false, 0, fmt.Errorf("decoding response from %s: %w", u, err)
+	}
+	return false, 0, nil
+}
+
+// retryAfter parses a Retry-After header, either seconds or an HTTP date, into the time left to wait from now.  It is
+// zero when the header is missing, invalid or in the past.
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		if seconds > int64(math.MaxInt64/time.Second) {
+			return math.MaxInt64
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// backoff returns the delay before retry number attempt+1: exponential growth capped at maxBackoff, with full jitter
+// so that many clients failing together do not retry in lockstep.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.initialBackoff << uint(attempt)
+	if d > c.maxBackoff || d <= 0 {
+		d = c.maxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// sleep waits for d, returning early with the context's error if it is cancelled first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
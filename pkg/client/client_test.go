@@ -0,0 +1,236 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// response is one canned answer of a test server.
+type response struct {
+	status     int
+	retryAfter string
+	body       string
+}
+
+// newServer starts a server answering with responses in turn, repeating the last one, and returns the requests it
+// received.
+func newServer(t *testing.T, responses ...response) (*httptest.Server, func() []*http.Request) {
+	var mu sync.Mutex
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r)
+		resp := responses[len(responses)-1]
+		if len(requests) <= len(responses) {
+			resp = responses[len(requests)-1]
+		}
+		mu.Unlock()
+
+		if resp.retryAfter != "" {
+			w.Header().Set("Retry-After", resp.retryAfter)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.status)
+		w.Write([]byte(resp.body))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []*http.Request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*http.Request(nil), requests...)
+	}
+}
+
+func newClient(t *testing.T, baseURL string, opts ...Option) *Client {
+	c, err := New(baseURL, append([]Option{WithRetries(2, time.Millisecond, 50*time.Millisecond)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+const park = `{"id":1,"location_num":"ADAM","location_name":"Adams National Historical Park","state":"MA","zip_code":2169}`
+
+func TestGetPark(t *testing.T) {
+	server, requests := newServer(t, response{status: http.StatusOK, body: park})
+	c := newClient(t, server.URL)
+
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     spanId,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	np, err := c.GetPark(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if np.Id != 1 || np.LocationName != "Adams National Historical Park" {
+		t.Errorf("GetPark() = %+v", np)
+	}
+
+	r := requests()[0]
+	if r.URL.Path != "/api/v1/nationalpark/1" {
+		t.Errorf("requested %s, want /api/v1/nationalpark/1", r.URL.Path)
+	}
+	if accept := r.Header.Get("Accept"); accept != "application/json" {
+		t.Errorf("Accept = %q, want application/json", accept)
+	}
+	if traceparent := r.Header.Get("traceparent"); !strings.HasPrefix(traceparent, "00-"+traceId.String()+"-") {
+		t.Errorf("traceparent = %q, want the caller's trace %s", traceparent, traceId)
+	}
+}
+
+func TestListParksQuery(t *testing.T) {
+	server, requests := newServer(t, response{status: http.StatusOK, body: "[" + park + "]"})
+	c := newClient(t, server.URL)
+
+	nps, err := c.ListParks(context.Background(), ListOptions{State: "MA", Page: Page{Start: 10, Count: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nps) != 1 {
+		t.Errorf("ListParks() returned %d parks, want 1", len(nps))
+	}
+	query := requests()[0].URL.Query()
+	if query.Get("state") != "MA" || query.Get("start") != "10" || query.Get("count") != "5" || query.Get("city") != "" {
+		t.Errorf("query = %v", query)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		responses      []response
+		wantAttempts   int
+		wantErr        error
+		wantRetryAfter time.Duration
+	}{
+		{name: "500 then success", responses: []response{{status: 500, body: `"boom"`}, {status: 200, body: park}},
+			wantAttempts: 2},
+		{name: "502 and 504 are retried", responses: []response{{status: 502}, {status: 504}, {status: 200, body: park}},
+			wantAttempts: 3},
+		{name: "retries run out", responses: []response{{status: 500, body: `"boom"`}},
+			wantAttempts: 3, wantErr: ErrServer},
+		{name: "503 without Retry-After backs off", responses: []response{{status: 503}, {status: 200, body: park}},
+			wantAttempts: 2},
+		{name: "503 asking for longer than the backoff", responses: []response{{status: 503, retryAfter: "7"}},
+			wantAttempts: 1, wantErr: ErrServer, wantRetryAfter: 7 * time.Second},
+		{name: "501 is not retried", responses: []response{{status: 501}},
+			wantAttempts: 1, wantErr: ErrServer},
+		{name: "505 is not retried", responses: []response{{status: 505}},
+			wantAttempts: 1, wantErr: ErrServer},
+		{name: "404 is not retried", responses: []response{{status: 404, body: `"national park 1 not found"`}},
+			wantAttempts: 1, wantErr: ErrNotFound},
+		{name: "400 is not retried", responses: []response{{status: 400, body: `"bad ZipCode: x"`}},
+			wantAttempts: 1, wantErr: ErrBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newServer(t, tt.responses...)
+			c := newClient(t, server.URL)
+
+			_, err := c.GetPark(context.Background(), 1)
+			if got := len(requests()); got != tt.wantAttempts {
+				t.Errorf("made %d attempts, want %d", got, tt.wantAttempts)
+			}
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("GetPark() = %v, want success", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetPark() = %v, want %v", err, tt.wantErr)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("GetPark() = %T, want *APIError", err)
+			}
+			if apiErr.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("RetryAfter = %s, want %s", apiErr.RetryAfter, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestRetryAfterIsHonored(t *testing.T) {
+	server, requests := newServer(t, response{status: 503, retryAfter: "1"}, response{status: 200, body: park})
+	c := newClient(t, server.URL, WithRetries(2, time.Millisecond, 2*time.Second))
+
+	start := time.Now()
+	if _, err := c.GetPark(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want the second the server asked for", elapsed)
+	}
+	if got := len(requests()); got != 2 {
+		t.Errorf("made %d attempts, want 2", got)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	server, _ := newServer(t, response{status: 404, body: `"national park 7 not found"`})
+	c := newClient(t, server.URL)
+
+	_, err := c.GetPark(context.Background(), 7)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 404 || apiErr.Message != "national park 7 not found" {
+		t.Errorf("GetPark() = %#v, want a 404 APIError with the decoded message", err)
+	}
+	if errors.Is(err, ErrServer) {
+		t.Error("a 404 matches ErrServer")
+	}
+}
+
+func TestNetworkError(t *testing.T) {
+	server, _ := newServer(t, response{status: 200})
+	server.Close()
+	c := newClient(t, server.URL)
+
+	_, err := c.GetPark(context.Background(), 1)
+	var apiErr *APIError
+	if err == nil || errors.As(err, &apiErr) {
+		t.Errorf("GetPark() = %v, want a network error", err)
+	}
+}
+
+func TestCancelStopsRetrying(t *testing.T) {
+	server, requests := newServer(t, response{status: 500})
+	c := newClient(t, server.URL, WithRetries(5, time.Hour, time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.GetPark(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetPark() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := len(requests()); got != 1 {
+		t.Errorf("made %d attempts, want 1", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 10, 12, 16, 0, 0, 0, time.UTC)
+	for value, want := range map[string]time.Duration{
+		"":                              0,
+		"0":                             0,
+		"-3":                            0,
+		"120":                           2 * time.Minute,
+		"soon":                          0,
+		"Tue, 12 Oct 2021 16:00:30 GMT": 30 * time.Second,
+		"Tue, 12 Oct 2021 15:59:00 GMT": 0,
+	} {
+		if got := retryAfter(value, now); got != want {
+			t.Errorf("retryAfter(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
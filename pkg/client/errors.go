@@ -0,0 +1,54 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Sentinel errors matching the error statuses documented in the OpenAPI document.  Use errors.Is to test an error
+// returned by the Client against them.
+var (
+	ErrBadRequest    = errors.New("bad request")
+	ErrNotFound      = errors.New("not found")
+	ErrNotAcceptable = errors.New("not acceptable")
+	ErrServer        = errors.New("server error")
+)
+
+// APIError is returned for every non-2xx response.  Message is the JSON encoded string the server sends as its error
+// body, or the raw body if it could not be decoded.
+type APIError struct {
+	StatusCode int
+	Message    string
+	// RetryAfter is how long the server asked to wait before trying again, zero without a Retry-After header.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("nationalparks-rest: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Is lets errors.Is match an APIError against the sentinel for its status code.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrNotAcceptable:
+		return e.StatusCode == http.StatusNotAcceptable
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+func newAPIError(statusCode int, body []byte) *APIError {
+	var message string
+	if err := json.Unmarshal(body, &message); err != nil {
+		message = string(body)
+	}
+	return &APIError{StatusCode: statusCode, Message: message}
+}
@@ -0,0 +1,612 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the configuration of the service.  Every setting has a default, and may be set in a YAML file, in an
+// environment variable and on the command line, which take precedence in that order; see Load.
+//
+// The OpenTelemetry exporters, sampler and propagators are configured with the standard OTEL_* environment variables
+// instead, like any other OpenTelemetry instrumented service.
+type Config struct {
+	DB       DBConfig       `yaml:"db"`
+	HTTP     HTTPConfig     `yaml:"http"`
+	CORS     CORSConfig     `yaml:"cors"`
+	GRPC     GRPCConfig     `yaml:"grpc"`
+	GraphQL  GraphQLConfig  `yaml:"graphql"`
+	Log      LogConfig      `yaml:"log"`
+	Admin    AdminConfig    `yaml:"admin"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Shutdown ShutdownConfig `yaml:"shutdown"`
+
+	// Where each setting came from, by key, for Print.
+	sources map[string]string
+}
+
+// DBConfig says how to connect to MySQL.  UserFile and PasswordFile, when set, take precedence over User and Password
+// and are read again for every new connection, so that credentials mounted from a Kubernetes secret can be rotated
+// without a restart.
+type DBConfig struct {
+	Host           string            `yaml:"host"`
+	Port           int               `yaml:"port"`
+	User           string            `yaml:"user"`
+	UserFile       string            `yaml:"user_file"`
+	Password       string            `yaml:"password"`
+	PasswordFile   string            `yaml:"password_file"`
+	Name           string            `yaml:"name"`
+	Params         map[string]string `yaml:"params"`
+	ConnectTimeout time.Duration     `yaml:"connect_timeout"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	QueryTimeout    time.Duration `yaml:"query_timeout"`
+	ReadRetries     int           `yaml:"read_retries"`
+	BreakerFailures int           `yaml:"breaker_failures"`
+	BreakerCooldown time.Duration `yaml:"breaker_cooldown"`
+
+	Replicas             []string      `yaml:"replicas"`
+	ReplicaPolicy        string        `yaml:"replica_policy"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval"`
+	PrimaryAfterWrite    time.Duration `yaml:"primary_after_write"`
+}
+
+// HTTPConfig says where to serve HTTP.  With TLSCertFile and TLSKeyFile set, HTTPS is served instead, and the gRPC
+// port requires TLS too.
+type HTTPConfig struct {
+	Host           string   `yaml:"host"`
+	Port           int      `yaml:"port"`
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	TLSCertFile     string `yaml:"tls_cert_file"`
+	TLSKeyFile      string `yaml:"tls_key_file"`
+	TLSMinVersion   string `yaml:"tls_min_version"`
+	TLSClientCAFile string `yaml:"tls_client_ca_file"`
+	TLSClientAuth   string `yaml:"tls_client_auth"`
+	H2C             bool   `yaml:"h2c"`
+}
+
+// CORSConfig says which browser scripts on other origins may call the service.  Without AllowedOrigins none may.
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+type GRPCConfig struct {
+	Port int `yaml:"port"`
+}
+
+type GraphQLConfig struct {
+	MaxDepth      int `yaml:"max_depth"`
+	MaxComplexity int `yaml:"max_complexity"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type AdminConfig struct {
+	Token string `yaml:"token"`
+}
+
+// AuthConfig holds the bearer tokens the API endpoints that change data require.  Without a token they are disabled.
+type AuthConfig struct {
+	WriteToken   string `yaml:"write_token"`
+	WebhookToken string `yaml:"webhook_token"`
+}
+
+type ShutdownConfig struct {
+	Delay   time.Duration `yaml:"delay"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Default returns the configuration used for everything that is not set elsewhere.
+func Default() *Config {
+	return &Config{
+		DB: DBConfig{
+			Host:           "localhost",
+			Port:           3306,
+			User:           "nationalparks_user",
+			Name:           "nationalparks_db",
+			ConnectTimeout: time.Minute,
+
+			MaxOpenConns:    20,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnMaxIdleTime: time.Minute,
+			QueryTimeout:    5 * time.Second,
+			ReadRetries:     2,
+			BreakerFailures: 5,
+			BreakerCooldown: 10 * time.Second,
+
+			ReplicaPolicy:        "round_robin",
+			ReplicaCheckInterval: 5 * time.Second,
+			PrimaryAfterWrite:    5 * time.Second,
+		},
+		HTTP: HTTPConfig{
+			Port:          8080,
+			TLSMinVersion: "1.2",
+			TLSClientAuth: "require",
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "DELETE"},
+			AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "Last-Event-ID", "traceparent", "tracestate", "baggage"},
+			ExposedHeaders: []string{"Server-Timing", "traceresponse", "Location", "Retry-After"},
+			MaxAge:         10 * time.Minute,
+		},
+		GRPC: GRPCConfig{
+			Port: 9090,
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      6,
+			MaxComplexity: 1000,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Shutdown: ShutdownConfig{
+			Timeout: 20 * time.Second,
+		},
+	}
+}
+
+// setting describes one configuration value and where it can be set.
+type setting struct {
+	key   string // section.name, as in the YAML file
+	env   string
+	flag  string // Empty for secrets, which would show up in the process list.
+	usage string
+	value value
+
+	// Redacted by Print.
+	secret bool
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "db.host", env: "DBHOST", flag: "db-host", usage: "Hostname or IP address of the MySQL server", value: stringValue{&c.DB.Host}},
+		{key: "db.port", env: "DBPORT", flag: "db-port", usage: "Port number the MySQL server is listening on", value: intValue{&c.DB.Port}},
+		{key: "db.user", env: "DBUSER", flag: "db-user", usage: "MySQL user name", value: stringValue{&c.DB.User}},
+		{key: "db.user_file", env: "DBUSER_FILE", flag: "db-user-file", usage: "File holding the MySQL user name, read for every new connection", value: stringValue{&c.DB.UserFile}},
+		{key: "db.password", env: "DBPASSWORD", usage: "MySQL password", value: stringValue{&c.DB.Password}, secret: true},
+		{key: "db.password_file", env: "DBPASSWORD_FILE", flag: "db-password-file", usage: "File holding the MySQL password, read for every new connection", value: stringValue{&c.DB.PasswordFile}},
+		{key: "db.name", env: "DBNAME", flag: "db-name", usage: "MySQL database name", value: stringValue{&c.DB.Name}},
+		{key: "db.params", env: "DBPARAMS", flag: "db-params", usage: "MySQL driver options, such as tls=true&timeout=5s", value: mapValue{&c.DB.Params}},
+		{key: "db.connect_timeout", env: "DB_CONNECT_TIMEOUT", flag: "db-connect-timeout", usage: "How long to retry the database at startup", value: durationValue{&c.DB.ConnectTimeout}},
+		{key: "db.max_open_conns", env: "DB_MAX_OPEN_CONNS", flag: "db-max-open-conns", usage: "Most connections open to the database, unlimited when 0", value: intValue{&c.DB.MaxOpenConns}},
+		{key: "db.max_idle_conns", env: "DB_MAX_IDLE_CONNS", flag: "db-max-idle-conns", usage: "Most idle connections kept open", value: intValue{&c.DB.MaxIdleConns}},
+		{key: "db.conn_max_lifetime", env: "DB_CONN_MAX_LIFETIME", flag: "db-conn-max-lifetime", usage: "How long a connection is used before it is replaced, forever when 0", value: durationValue{&c.DB.ConnMaxLifetime}},
+		{key: "db.conn_max_idle_time", env: "DB_CONN_MAX_IDLE_TIME", flag: "db-conn-max-idle-time", usage: "How long a connection may be idle before it is closed, forever when 0", value: durationValue{&c.DB.ConnMaxIdleTime}},
+		{key: "db.query_timeout", env: "DB_QUERY_TIMEOUT", flag: "db-query-timeout", usage: "How long a query or transaction may take, bounded by the request only when 0", value: durationValue{&c.DB.QueryTimeout}},
+		{key: "db.read_retries", env: "DB_READ_RETRIES", flag: "db-read-retries", usage: "How often a read failing with a broken connection or deadlock is retried", value: intValue{&c.DB.ReadRetries}},
+		{key: "db.breaker_failures", env: "DB_BREAKER_FAILURES", flag: "db-breaker-failures", usage: "Database failures in a row after which queries fail fast, never when 0", value: intValue{&c.DB.BreakerFailures}},
+		{key: "db.breaker_cooldown", env: "DB_BREAKER_COOLDOWN", flag: "db-breaker-cooldown", usage: "How long queries fail fast before the database is tried again", value: durationValue{&c.DB.BreakerCooldown}},
+		{key: "db.replicas", env: "DBREPLICAS", flag: "db-replicas", usage: "Comma separated host[:port] of read replicas, which share the credentials, name and options of the primary", value: listValue{&c.DB.Replicas}},
+		{key: "db.replica_policy", env: "DB_REPLICA_POLICY", flag: "db-replica-policy", usage: "How reads are spread over the replicas: round_robin or least_connections", value: stringValue{&c.DB.ReplicaPolicy}},
+		{key: "db.replica_check_interval", env: "DB_REPLICA_CHECK_INTERVAL", flag: "db-replica-check-interval", usage: "How often the replicas are pinged", value: durationValue{&c.DB.ReplicaCheckInterval}},
+		{key: "db.primary_after_write", env: "DB_PRIMARY_AFTER_WRITE", flag: "db-primary-after-write", usage: "How long the reads of a client that wrote go to the primary", value: durationValue{&c.DB.PrimaryAfterWrite}},
+		{key: "http.host", env: "HTTPHOST", flag: "http-host", usage: "Address to accept HTTP and gRPC connections on, all interfaces when empty", value: stringValue{&c.HTTP.Host}},
+		{key: "http.port", env: "HTTPPORT", flag: "http-port", usage: "Port number to accept HTTP connections on", value: intValue{&c.HTTP.Port}},
+		{key: "http.trusted_proxies", env: "TRUSTED_PROXIES", flag: "trusted-proxies", usage: "Comma separated addresses and CIDR ranges of the proxies whose X-Forwarded-For is trusted", value: listValue{&c.HTTP.TrustedProxies}},
+		{key: "http.tls_cert_file", env: "TLS_CERT_FILE", flag: "tls-cert-file", usage: "PEM certificate chain to serve HTTPS and gRPC over TLS with, reloaded when it changes", value: stringValue{&c.HTTP.TLSCertFile}},
+		{key: "http.tls_key_file", env: "TLS_KEY_FILE", flag: "tls-key-file", usage: "PEM private key of the certificate, reloaded when it changes", value: stringValue{&c.HTTP.TLSKeyFile}},
+		{key: "http.tls_min_version", env: "TLS_MIN_VERSION", flag: "tls-min-version", usage: "Oldest TLS version accepted: 1.0, 1.1, 1.2 or 1.3", value: stringValue{&c.HTTP.TLSMinVersion}},
+		{key: "http.tls_client_ca_file", env: "TLS_CLIENT_CA_FILE", flag: "tls-client-ca-file", usage: "PEM certificates of the CAs client certificates must be signed by, for mutual TLS", value: stringValue{&c.HTTP.TLSClientCAFile}},
+		{key: "http.tls_client_auth", env: "TLS_CLIENT_AUTH", flag: "tls-client-auth", usage: "With a client CA, whether clients must present a certificate: require or verify_if_given", value: stringValue{&c.HTTP.TLSClientAuth}},
+		{key: "http.h2c", env: "H2C", flag: "h2c", usage: "Serve HTTP/2 without TLS as well as HTTP/1.1, for clients that know the server speaks it", value: boolValue{&c.HTTP.H2C}},
+		{key: "cors.allowed_origins", env: "CORS_ALLOWED_ORIGINS", flag: "cors-allowed-origins", usage: "Comma separated origins, such as https://*.example.com, whose scripts may call the service", value: listValue{&c.CORS.AllowedOrigins}},
+		{key: "cors.allowed_methods", env: "CORS_ALLOWED_METHODS", flag: "cors-allowed-methods", usage: "Comma separated methods cross-origin requests may use", value: listValue{&c.CORS.AllowedMethods}},
+		{key: "cors.allowed_headers", env: "CORS_ALLOWED_HEADERS", flag: "cors-allowed-headers", usage: "Comma separated headers cross-origin requests may send", value: listValue{&c.CORS.AllowedHeaders}},
+		{key: "cors.exposed_headers", env: "CORS_EXPOSED_HEADERS", flag: "cors-exposed-headers", usage: "Comma separated response headers cross-origin scripts may read", value: listValue{&c.CORS.ExposedHeaders}},
+		{key: "cors.allow_credentials", env: "CORS_ALLOW_CREDENTIALS", flag: "cors-allow-credentials", usage: "Whether cross-origin requests may send cookies and client certificates", value: boolValue{&c.CORS.AllowCredentials}},
+		{key: "cors.max_age", env: "CORS_MAX_AGE", flag: "cors-max-age", usage: "How long browsers may cache the answer to a preflight request", value: durationValue{&c.CORS.MaxAge}},
+		{key: "grpc.port", env: "GRPCPORT", flag: "grpc-port", usage: "Port number to accept gRPC connections on", value: intValue{&c.GRPC.Port}},
+		{key: "graphql.max_depth", env: "GRAPHQL_MAX_DEPTH", flag: "graphql-max-depth", usage: "Deepest GraphQL query accepted", value: intValue{&c.GraphQL.MaxDepth}},
+		{key: "graphql.max_complexity", env: "GRAPHQL_MAX_COMPLEXITY", flag: "graphql-max-complexity", usage: "Most complex GraphQL query accepted", value: intValue{&c.GraphQL.MaxComplexity}},
+		{key: "log.level", env: "LOG_LEVEL", flag: "log-level", usage: "Log level: panic, fatal, error, warn, info, debug or trace", value: stringValue{&c.Log.Level}},
+		{key: "log.format", env: "LOG_FORMAT", flag: "log-format", usage: "Log format: json or text", value: stringValue{&c.Log.Format}},
+		{key: "admin.token", env: "ADMIN_TOKEN", usage: "Bearer token required by the admin endpoints that change the service, which are disabled without one", value: stringValue{&c.Admin.Token}, secret: true},
+		{key: "auth.write_token", env: "WRITE_TOKEN", usage: "Bearer token required to create, update and delete parks, which is disabled without one", value: stringValue{&c.Auth.WriteToken}, secret: true},
+		{key: "auth.webhook_token", env: "WEBHOOK_TOKEN", usage: "Bearer token required to manage webhook subscriptions and dead letters, which is disabled without one", value: stringValue{&c.Auth.WebhookToken}, secret: true},
+		{key: "shutdown.delay", env: "SHUTDOWN_DELAY", flag: "shutdown-delay", usage: "How long to keep serving with failing readiness after SIGTERM", value: durationValue{&c.Shutdown.Delay}},
+		{key: "shutdown.timeout", env: "SHUTDOWN_TIMEOUT", flag: "shutdown-timeout", usage: "How long requests in flight have to finish at shutdown", value: durationValue{&c.Shutdown.Timeout}},
+	}
+}
+
+// Load returns the configuration given by, from lowest to highest precedence:
+//
+//  1. the defaults,
+//  2. the YAML file named by the -config flag or the CONFIG_FILE environment variable, if any,
+//  3. environment variables, empty ones are ignored since Docker passes variables that are not set as empty,
+//  4. the command line flags in args.
+//
+// It fails with every value that could not be parsed or is out of range.  With -h it prints the usage and returns
+// flag.ErrHelp.
+func Load(args []string) (*Config, error) {
+	c := Default()
+	c.sources = make(map[string]string)
+	settings := c.settings()
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [config print] [flags]\n\n", fs.Name())
+		fmt.Fprintf(fs.Output(), "config print shows the effective configuration, with secrets redacted, and exits.\n\n")
+		fs.PrintDefaults()
+	}
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration file")
+	for _, s := range settings {
+		if b, ok := s.value.(boolValue); ok && s.flag != "" {
+			fs.Bool(s.flag, *b.p, s.usage)
+		} else if s.flag != "" {
+			fs.String(s.flag, s.value.String(), s.usage)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if *configFile != "" {
+		if err := c.loadFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var problems []string
+	for _, s := range settings {
+		if val, exists := os.LookupEnv(s.env); exists == true && val != "" {
+			if err := s.value.Set(val); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", s.env, err))
+			}
+			c.sources[s.key] = "env " + s.env
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name {
+				if err := s.value.Set(f.Value.String()); err != nil {
+					problems = append(problems, fmt.Sprintf("-%s: %v", s.flag, err))
+				}
+				c.sources[s.key] = "flag -" + s.flag
+			}
+		}
+	})
+	problems = append(problems, c.validate()...)
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return c, nil
+}
+
+// loadFile reads the settings in the YAML file name.  Unknown keys are errors, they are most likely typos.
+func (c *Config) loadFile(name string) error {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("reading the configuration file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("configuration file %s: %v", name, err)
+	}
+
+	// Note which settings the file set, for Print.
+	var sections map[string]map[string]interface{}
+	if err = yaml.Unmarshal(content, &sections); err == nil {
+		for section, values := range sections {
+			for key := range values {
+				c.sources[section+"."+key] = "file " + name
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+	checkPort := func(key string, port int) {
+		if port < 1 || port > 65535 {
+			problems = append(problems, fmt.Sprintf("%s: %d is not a port number", key, port))
+		}
+	}
+	checkPositive := func(key string, n int) {
+		if n <= 0 {
+			problems = append(problems, fmt.Sprintf("%s: must be positive, not %d", key, n))
+		}
+	}
+	checkNotNegativeInt := func(key string, n int) {
+		if n < 0 {
+			problems = append(problems, fmt.Sprintf("%s: must not be negative, not %d", key, n))
+		}
+	}
+	checkNotNegative := func(key string, d time.Duration) {
+		if d < 0 {
+			problems = append(problems, fmt.Sprintf("%s: must not be negative, not %s", key, d))
+		}
+	}
+
+	if c.DB.Host == "" {
+		problems = append(problems, "db.host: is required")
+	}
+	checkPort("db.port", c.DB.Port)
+	if c.DB.User == "" && c.DB.UserFile == "" {
+		problems = append(problems, "db.user: is required, or db.user_file")
+	}
+	if c.DB.Password == "" && c.DB.PasswordFile == "" {
+		problems = append(problems, "db.password: is required, or db.password_file")
+	}
+	checkReadable := func(key string, name string) {
+		if name == "" {
+			return
+		}
+		if f, err := os.Open(name); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+		} else {
+			f.Close()
+		}
+	}
+	if c.DB.Name == "" {
+		problems = append(problems, "db.name: is required")
+	}
+	checkReadable("db.user_file", c.DB.UserFile)
+	checkReadable("db.password_file", c.DB.PasswordFile)
+	checkNotNegative("db.connect_timeout", c.DB.ConnectTimeout)
+	checkNotNegativeInt("db.max_open_conns", c.DB.MaxOpenConns)
+	checkNotNegativeInt("db.max_idle_conns", c.DB.MaxIdleConns)
+	if c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		problems = append(problems, fmt.Sprintf("db.max_idle_conns: must not exceed db.max_open_conns, %d is more than %d", c.DB.MaxIdleConns, c.DB.MaxOpenConns))
+	}
+	checkNotNegative("db.conn_max_lifetime", c.DB.ConnMaxLifetime)
+	checkNotNegative("db.conn_max_idle_time", c.DB.ConnMaxIdleTime)
+	checkNotNegative("db.query_timeout", c.DB.QueryTimeout)
+	checkNotNegativeInt("db.read_retries", c.DB.ReadRetries)
+	checkNotNegativeInt("db.breaker_failures", c.DB.BreakerFailures)
+	if c.DB.BreakerFailures > 0 && c.DB.BreakerCooldown <= 0 {
+		problems = append(problems, fmt.Sprintf("db.breaker_cooldown: must be positive, not %s", c.DB.BreakerCooldown))
+	}
+	for _, replica := range c.DB.Replicas {
+		if _, _, err := SplitHostPort(replica, c.DB.Port); err != nil {
+			problems = append(problems, fmt.Sprintf("db.replicas: %v", err))
+		}
+	}
+	if policy := c.DB.ReplicaPolicy; policy != "round_robin" && policy != "least_connections" {
+		problems = append(problems, fmt.Sprintf("db.replica_policy: %q is not round_robin or least_connections", policy))
+	}
+	if c.DB.ReplicaCheckInterval <= 0 {
+		problems = append(problems, fmt.Sprintf("db.replica_check_interval: must be positive, not %s", c.DB.ReplicaCheckInterval))
+	}
+	checkNotNegative("db.primary_after_write", c.DB.PrimaryAfterWrite)
+	checkPort("http.port", c.HTTP.Port)
+	if (c.HTTP.TLSCertFile == "") != (c.HTTP.TLSKeyFile == "") {
+		problems = append(problems, "http.tls_cert_file: is required together with http.tls_key_file")
+	}
+	checkReadable("http.tls_cert_file", c.HTTP.TLSCertFile)
+	checkReadable("http.tls_key_file", c.HTTP.TLSKeyFile)
+	checkReadable("http.tls_client_ca_file", c.HTTP.TLSClientCAFile)
+	switch c.HTTP.TLSMinVersion {
+	case "1.0", "1.1", "1.2", "1.3":
+	default:
+		problems = append(problems, fmt.Sprintf("http.tls_min_version: %q is not one of 1.0, 1.1, 1.2 or 1.3", c.HTTP.TLSMinVersion))
+	}
+	if c.HTTP.TLSClientAuth != "require" && c.HTTP.TLSClientAuth != "verify_if_given" {
+		problems = append(problems, fmt.Sprintf("http.tls_client_auth: %q is not require or verify_if_given", c.HTTP.TLSClientAuth))
+	}
+	if c.HTTP.TLSClientCAFile != "" && c.HTTP.TLSCertFile == "" {
+		problems = append(problems, "http.tls_client_ca_file: requires http.tls_cert_file and http.tls_key_file")
+	}
+	if c.HTTP.H2C && c.HTTP.TLSCertFile != "" {
+		problems = append(problems, "http.h2c: only applies without TLS, which negotiates HTTP/2 by itself")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if err := checkOrigin(origin); err != nil {
+			problems = append(problems, fmt.Sprintf("cors.allowed_origins: %v", err))
+		}
+	}
+	checkNotNegative("cors.max_age", c.CORS.MaxAge)
+	checkPort("grpc.port", c.GRPC.Port)
+	if c.GRPC.Port == c.HTTP.Port {
+		problems = append(problems, fmt.Sprintf("grpc.port: must differ from http.port, both are %d", c.GRPC.Port))
+	}
+	checkPositive("graphql.max_depth", c.GraphQL.MaxDepth)
+	checkPositive("graphql.max_complexity", c.GraphQL.MaxComplexity)
+	if _, err := log.ParseLevel(c.Log.Level); err != nil {
+		problems = append(problems, fmt.Sprintf("log.level: %q is not one of panic, fatal, error, warn, info, debug or trace", c.Log.Level))
+	}
+	if format := strings.ToLower(c.Log.Format); format != "json" && format != "text" {
+		problems = append(problems, fmt.Sprintf("log.format: %q is not json or text", c.Log.Format))
+	}
+	checkNotNegative("shutdown.delay", c.Shutdown.Delay)
+	checkNotNegative("shutdown.timeout", c.Shutdown.Timeout)
+	return problems
+}
+
+// SplitHostPort splits a host[:port] address, such as a replica, into its host and port, which defaults to
+// defaultPort.
+func SplitHostPort(address string, defaultPort int) (string, int, error) {
+	host, port := address, defaultPort
+	if h, p, err := net.SplitHostPort(address); err == nil {
+		if port, err = strconv.Atoi(p); err != nil || port < 1 || port > 65535 {
+			return "", 0, fmt.Errorf("%q has an invalid port", address)
+		}
+		host = h
+	}
+	if host == "" || strings.ContainsAny(host, ":/@") {
+		return "", 0, fmt.Errorf("%q is not a host[:port] address", address)
+	}
+	return host, port, nil
+}
+
+// checkOrigin checks that origin is a scheme://host[:port] origin, whose host may have one wildcard, as in
+// https://*.example.com.  Allowing every origin with a lone * would let any web page write to the service.
+func checkOrigin(origin string) error {
+	if origin == "*" {
+		return fmt.Errorf("%q would allow every origin, list them instead", origin)
+	}
+	if strings.Count(origin, "*") > 1 {
+		return fmt.Errorf("%q has more than one wildcard", origin)
+	}
+	u, err := url.Parse(strings.Replace(origin, "*", "x", 1))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return fmt.Errorf("%q is not a scheme://host[:port] origin", origin)
+	}
+	return nil
+}
+
+// Print writes the configuration to w in the format of the configuration file, noting where each value came from.
+// Secrets are redacted.
+func (c *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := make(map[string]*yaml.Node)
+	for _, s := range c.settings() {
+		parts := strings.SplitN(s.key, ".", 2)
+		section, exists := sections[parts[0]]
+		if !exists {
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			sections[parts[0]] = section
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: parts[0]}, section)
+		}
+
+		value := &yaml.Node{}
+		if s.secret && s.value.String() != "" {
+			value.SetString("<redacted>")
+		} else if err := value.Encode(s.value.get()); err != nil {
+			return err
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: parts[1]}
+		if source, exists := c.sources[s.key]; exists {
+			key.LineComment = source
+		}
+		section.Content = append(section.Content, key, value)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// value is a flag.Value bound to a Config field.
+type value interface {
+	flag.Value
+
+	// get returns the value to print.
+	get() interface{}
+}
+
+type stringValue struct{ p *string }
+
+func (v stringValue) String() string     { return *v.p }
+func (v stringValue) Set(s string) error { *v.p = s; return nil }
+func (v stringValue) get() interface{}   { return *v.p }
+
+type intValue struct{ p *int }
+
+func (v intValue) String() string   { return fmt.Sprint(*v.p) }
+func (v intValue) get() interface{} { return *v.p }
+func (v intValue) Set(s string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not a whole number", s)
+	}
+	*v.p = n
+	return nil
+}
+
+type durationValue struct{ p *time.Duration }
+
+func (v durationValue) String() string   { return v.p.String() }
+func (v durationValue) get() interface{} { return v.p.String() }
+func (v durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration such as 30s or 1m", s)
+	}
+	*v.p = d
+	return nil
+}
+
+type mapValue struct{ p *map[string]string }
+
+func (v mapValue) String() string {
+	values := make(url.Values)
+	for key, val := range *v.p {
+		values.Set(key, val)
+	}
+	return values.Encode()
+}
+func (v mapValue) get() interface{} {
+	if *v.p == nil {
+		return map[string]string{}
+	}
+	return *v.p
+}
+func (v mapValue) Set(s string) error {
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a list of key=value pairs separated by &", s)
+	}
+	m := make(map[string]string)
+	for key := range values {
+		m[key] = values.Get(key)
+	}
+	*v.p = m
+	return nil
+}
+
+type boolValue struct{ p *bool }
+
+func (v boolValue) String() string   { return strconv.FormatBool(*v.p) }
+func (v boolValue) get() interface{} { return *v.p }
+func (v boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not true or false", s)
+	}
+	*v.p = b
+	return nil
+}
+
+type listValue struct{ p *[]string }
+
+func (v listValue) String() string { return strings.Join(*v.p, ",") }
+func (v listValue) get() interface{} {
+	if *v.p == nil {
+		return []string{}
+	}
+	return *v.p
+}
+func (v listValue) Set(s string) error {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*v.p = list
+	return nil
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isolate clears every environment variable Load reads, as empty ones are ignored, and sets the password so that the
+// configuration is valid.
+func isolate(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	for _, s := range Default().settings() {
+		t.Setenv(s.env, "")
+	}
+	t.Setenv("DBPASSWORD", "secret")
+}
+
+func writeFile(t *testing.T, content string) string {
+	name := filepath.Join(t.TempDir(), "nationalparks-rest.yaml")
+	if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := "db:\n  host: file.example.com\n  port: 3307\nlog:\n  level: warn\n"
+	for _, tt := range []struct {
+		name     string
+		file     bool
+		env      map[string]string
+		args     []string
+		wantHost string
+		wantPort int
+		wantLog  string
+	}{
+		{name: "defaults", wantHost: "localhost", wantPort: 3306, wantLog: "info"},
+		{name: "file over defaults", file: true, wantHost: "file.example.com", wantPort: 3307, wantLog: "warn"},
+		{name: "env over file", file: true, env: map[string]string{"DBHOST": "env.example.com"},
+			wantHost: "env.example.com", wantPort: 3307, wantLog: "warn"},
+		{name: "empty env is ignored", file: true, env: map[string]string{"DBHOST": ""},
+			wantHost: "file.example.com", wantPort: 3307, wantLog: "warn"},
+		{name: "flags over env", file: true, env: map[string]string{"DBHOST": "env.example.com", "LOG_LEVEL": "debug"},
+			args: []string{"-db-host", "flag.example.com"}, wantHost: "flag.example.com", wantPort: 3307, wantLog: "debug"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			if tt.file {
+				t.Setenv("CONFIG_FILE", writeFile(t, file))
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			c, err := Load(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.DB.Host != tt.wantHost || c.DB.Port != tt.wantPort || c.Log.Level != tt.wantLog {
+				t.Errorf("db.host, db.port, log.level = %s, %d, %s, want %s, %d, %s",
+					c.DB.Host, c.DB.Port, c.Log.Level, tt.wantHost, tt.wantPort, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFlagOverridesEnv(t *testing.T) {
+	isolate(t)
+	t.Setenv("CONFIG_FILE", writeFile(t, "db:\n  host: env-file.example.com\n"))
+	flagFile := writeFile(t, "db:\n  host: flag-file.example.com\n")
+
+	c, err := Load([]string{"-config", flagFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DB.Host != "flag-file.example.com" {
+		t.Errorf("db.host = %s, want the one from the -config file", c.DB.Host)
+	}
+}
+
+func TestLoadParsesValues(t *testing.T) {
+	isolate(t)
+	t.Setenv("DB_QUERY_TIMEOUT", "250ms")
+	t.Setenv("DBREPLICAS", "replica-1, replica-2:3307")
+	t.Setenv("H2C", "true")
+
+	c, err := Load([]string{"-db-params", "tls=true&timeout=5s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DB.QueryTimeout != 250*time.Millisecond {
+		t.Errorf("db.query_timeout = %s, want 250ms", c.DB.QueryTimeout)
+	}
+	if strings.Join(c.DB.Replicas, "|") != "replica-1|replica-2:3307" {
+		t.Errorf("db.replicas = %q", c.DB.Replicas)
+	}
+	if !c.HTTP.H2C {
+		t.Error("http.h2c = false, want true")
+	}
+	if c.DB.Params["tls"] != "true" || c.DB.Params["timeout"] != "5s" {
+		t.Errorf("db.params = %v", c.DB.Params)
+	}
+}
+
+func TestLoadReportsProblems(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		want []string
+	}{
+		{name: "unparsable values", env: map[string]string{"DBPORT": "mysql", "DB_QUERY_TIMEOUT": "5"},
+			want: []string{"DBPORT: ", "DB_QUERY_TIMEOUT: "}},
+		{name: "out of range", args: []string{"-http-port", "70000", "-graphql-max-depth", "0"},
+			want: []string{"http.port: 70000 is not a port number", "graphql.max_depth: must be positive, not 0"}},
+		{name: "conflicting ports", env: map[string]string{"HTTPPORT": "9090"},
+			want: []string{"grpc.port: must differ from http.port, both are 9090"}},
+		{name: "unknown choices", env: map[string]string{"LOG_LEVEL": "loud", "DB_REPLICA_POLICY": "random"},
+			want: []string{`log.level: "loud" is not one of`, `db.replica_policy: "random" is not round_robin or least_connections`}},
+		{name: "every origin", env: map[string]string{"CORS_ALLOWED_ORIGINS": "*"},
+			want: []string{`cors.allowed_origins: "*" would allow every origin`}},
+		{name: "missing password", env: map[string]string{"DBPASSWORD": ""},
+			want: []string{"db.password: is required, or db.password_file"}},
+		{name: "unknown file key", file: "db:\n  hostname: typo.example.com\n",
+			want: []string{"field hostname not found"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			if tt.file != "" {
+				t.Setenv("CONFIG_FILE", writeFile(t, tt.file))
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			_, err := Load(tt.args)
+			if err == nil {
+				t.Fatal("Load() succeeded")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Load() = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	isolate(t)
+	t.Setenv("DBPASSWORD", "db-password-value")
+	t.Setenv("WRITE_TOKEN", "write-token-value")
+	t.Setenv("DBHOST", "env.example.com")
+
+	c, err := Load([]string{"-db-name", "parks"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err = c.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	printed := out.String()
+
+	for _, secret := range []string{"db-password-value", "write-token-value"} {
+		if strings.Contains(printed, secret) {
+			t.Errorf("the printed configuration contains the secret %q:\n%s", secret, printed)
+		}
+	}
+	for _, want := range []string{
+		"password: <redacted> # env DBPASSWORD",
+		"write_token: <redacted> # env WRITE_TOKEN",
+		// Secrets that are not set are shown as empty rather than redacted.
+		`webhook_token: ""`,
+		"host: env.example.com # env DBHOST",
+		"name: parks # flag -db-name",
+	} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("the printed configuration does not contain %q:\n%s", want, printed)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"nationalparks-rest/pkg"
+	"time"
+)
+
+// Event types recorded in the change log.
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// NationalParkChange is an entry of the change log.  For deleted parks Park holds the park as it was before it was
+// deleted.
+type NationalParkChange struct {
+	Id   int64        `json:"id"`
+	Type string       `json:"type"`
+	Time time.Time    `json:"time"`
+	Park NationalPark `json:"park"`
+}
+
+// DBCreateNationalPark inserts np, ignoring its Id, and returns it with the Id assigned by the database.
+func DBCreateNationalPark(ctx context.Context, db *sql.DB, np NationalPark) (NationalPark, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBCreateNationalPark")
+	defer span.End()
+
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, "INSERT INTO NATIONAL_PARKS (LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, "+
+			"ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			np.LocationNum, np.LocationName, np.Address, np.City, np.State, np.ZipCode, np.PhoneNum, np.FaxNum, np.Latitude, np.Longitude)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		np.Id = int(id)
+		return recordChange(ctx, tx, ChangeCreated, np)
+	})
+	span.SetAttributes(attribute.Int("id", np.Id))
+	return np, err
+}
+
+// DBUpdateNationalPark replaces every column of the park with np.Id.  It returns sql.ErrNoRows if there is no such
+// park.
+func DBUpdateNationalPark(ctx context.Context, db *sql.DB, np NationalPark) (NationalPark, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBUpdateNationalPark")
+	defer span.End()
+	span.SetAttributes(attribute.Int("id", np.Id))
+
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		// MySQL reports zero affected rows for an update that changes nothing, so check that the park exists first.
+		if _, err := lockNationalPark(ctx, tx, np.Id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE NATIONAL_PARKS SET LOCATION_NUM=?, LOCATION_NAME=?, ADDRESS=?, CITY=?, STATE=?, "+
+			"ZIP_CODE=?, PHONE_NUM=?, FAX_NUM=?, LATITUDE=?, LONGITUDE=? WHERE ID=?",
+			np.LocationNum, np.LocationName, np.Address, np.City, np.State, np.ZipCode, np.PhoneNum, np.FaxNum, np.Latitude, np.Longitude, np.Id)
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, ChangeUpdated, np)
+	})
+	return np, err
+}
+
+// DBDeleteNationalPark deletes the park with the given id and returns it.  It returns sql.ErrNoRows if there is no
+// such park.
+func DBDeleteNationalPark(ctx context.Context, db *sql.DB, id int) (NationalPark, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBDeleteNationalPark")
+	defer span.End()
+	span.SetAttributes(attribute.Int("id", id))
+
+	var np NationalPark
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		var err error
+		if np, err = lockNationalPark(ctx, tx, id); err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, "DELETE FROM NATIONAL_PARKS WHERE ID=?", id); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, ChangeDeleted, np)
+	})
+	return np, err
+}
+
+// DBGetNationalParkChanges returns up to count change log entries with an id greater than afterId, oldest first.
+func DBGetNationalParkChanges(ctx context.Context, db *sql.DB, afterId int64, count int) ([]NationalParkChange, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBGetNationalParkChanges")
+	defer span.End()
+	defer observeQuery(ctx, "DBGetNationalParkChanges", time.Now())
+	span.SetAttributes(attribute.Int64("after-id", afterId))
+
+	var changes []NationalParkChange
+	err := runRead(ctx, db, func(ctx context.Context, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, "SELECT ID, EVENT_TYPE, CREATED_AT, PAYLOAD FROM NATIONAL_PARK_CHANGES "+
+			"WHERE ID > ? ORDER BY ID LIMIT ?", afterId, count)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		changes = []NationalParkChange{}
+		for rows.Next() {
+			var change NationalParkChange
+			var payload []byte
+			if err = rows.Scan(&change.Id, &change.Type, &change.Time, &payload); err != nil {
+				return err
+			}
+			if err = json.Unmarshal(payload, &change.Park); err != nil {
+				return err
+			}
+			changes = append(changes, change)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("rows", len(changes)))
+	observeRows(ctx, "DBGetNationalParkChanges", len(changes))
+	return changes, nil
+}
+
+// DBGetLatestNationalParkChangeId returns the id of the newest change log entry, or 0 if the log is empty.
+func DBGetLatestNationalParkChangeId(ctx context.Context, db *sql.DB) (int64, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBGetLatestNationalParkChangeId")
+	defer span.End()
+	defer observeQuery(ctx, "DBGetLatestNationalParkChangeId", time.Now())
+
+	var id int64
+	err := runRead(ctx, db, func(ctx context.Context, db *sql.DB) error {
+		return db.QueryRowContext(ctx, "SELECT COALESCE(MAX(ID), 0) FROM NATIONAL_PARK_CHANGES").Scan(&id)
+	})
+	return id, err
+}
+
+// recordChange appends a change to the log as part of the transaction making it.
+func recordChange(ctx context.Context, tx *sql.Tx, eventType string, np NationalPark) error {
+	payload, err := json.Marshal(np)
+	if err != nil {
+		return err
+	}
+
+	// The sequence row stays locked until the transaction ends, so writers commit in id order.  With AUTO_INCREMENT a
+	// reader could see id n+1 before a slower transaction commits id n, and a subscriber resuming after n+1 would
+	// never see n.
+	result, err := tx.ExecContext(ctx, "UPDATE NATIONAL_PARK_CHANGE_SEQUENCE SET ID = LAST_INSERT_ID(ID + 1)")
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	_, err = tx.ExecContext(ctx, "INSERT INTO NATIONAL_PARK_CHANGES (ID, EVENT_TYPE, PARK_ID, PAYLOAD, CREATED_AT) VALUES (?, ?, ?, ?, ?)",
+		id, eventType, np.Id, payload, now)
+	if err != nil {
+		return err
+	}
+
+	return queueWebhookDeliveries(ctx, tx, id, eventType, now)
+}
+
+func lockNationalPark(ctx context.Context, tx *sql.Tx, id int) (NationalPark, error) {
+	var np NationalPark
+	var row = tx.QueryRowContext(ctx, "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE FROM NATIONAL_PARKS WHERE ID=? FOR UPDATE", id)
+	return np, row.Scan(&np.Id, &np.LocationNum, &np.LocationName, &np.Address, &np.City, &np.State, &np.ZipCode, &np.PhoneNum, &np.FaxNum, &np.Latitude, &np.Longitude)
+}
+
+// inTx runs fn in a transaction on the primary that is committed if fn succeeds and rolled back otherwise.  The
+// transaction gets the query timeout and is not retried.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	defer pkg.TimerFromContext(ctx).Start(pkg.PhaseDB)()
+
+	err := runQuery(ctx, nil, queryTimeout, func(ctx context.Context) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err = fn(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+	if err == nil {
+		recordWrite(ctx)
+	}
+	return err
+}
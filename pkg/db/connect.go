@@ -0,0 +1,193 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Delays between connection attempts, doubled after every failure up to the maximum.
+const connectMinBackoff = 500 * time.Millisecond
+const connectMaxBackoff = 15 * time.Second
+
+// WaitForDB pings db until it answers or timeout has passed.  sql.Open does not connect, so without this the service
+// would start and fail every request while the database is still starting or unreachable.
+func WaitForDB(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "WaitForDB")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := connectMinBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			span.RecordError(err)
+			return fmt.Errorf("database not reachable after %d attempts in %s: %w", attempt, timeout, err)
+		}
+
+		log.Warnf("Database not reachable, retrying in %s: %v", backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			span.RecordError(err)
+			return fmt.Errorf("database not reachable after %d attempts in %s: %w", attempt, timeout, err)
+		}
+		if backoff *= 2; backoff > connectMaxBackoff {
+			backoff = connectMaxBackoff
+		}
+	}
+}
+
+// CheckServerVersion fails unless db is MySQL 8.0 or later.  Webhook dispatchers claim deliveries with FOR UPDATE OF
+// ... SKIP LOCKED, which older versions and MariaDB reject as a syntax error.
+func CheckServerVersion(ctx context.Context, db *sql.DB) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "CheckServerVersion")
+	defer span.End()
+
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return fmt.Errorf("reading the MySQL version: %w", err)
+	}
+	span.SetAttributes(attribute.String("version", version))
+	if !supportedServerVersion(version) {
+		return fmt.Errorf("MySQL 8.0 or later is required, the database server is %s", version)
+	}
+	return nil
+}
+
+// supportedServerVersion reports whether a VERSION() such as 8.0.33 or 5.7.42-log is MySQL 8.0 or later.
+func supportedServerVersion(version string) bool {
+	if strings.Contains(strings.ToLower(version), "mariadb") {
+		return false
+	}
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	return err == nil && major >= 8
+}
+
+// Credentials returns the user name and password to connect with.
+type Credentials func() (user string, password string, err error)
+
+// FileCredentials returns Credentials that read the user name from userFile and the password from passwordFile every
+// time they are called, so that rotated secrets are picked up.  Files that are not set fall back to user and password.
+// Surrounding whitespace in the files, such as the newline most editors add, is ignored.
+func FileCredentials(user string, userFile string, password string, passwordFile string) Credentials {
+	return func() (string, string, error) {
+		var err error
+		if userFile != "" {
+			if user, err = readSecretFile(userFile); err != nil {
+				return "", "", err
+			}
+		}
+		if passwordFile != "" {
+			if password, err = readSecretFile(passwordFile); err != nil {
+				return "", "", err
+			}
+		}
+		return user, password, nil
+	}
+}
+
+func readSecretFile(name string) (string, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("reading database credentials: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// NewConnector returns a connector for sql.OpenDB that opens connections to the database dbname at hostname:port
+// through drv, which must implement driver.DriverContext like the MySQL driver does.  The credentials are asked for on
+// every new connection, connections opened before they changed stay open.  params are MySQL driver
+// options such as tls or timeout, on top of parseTime and interpolateParams which the queries rely on.
+func NewConnector(drv driver.Driver, credentials Credentials, hostname string, port int, dbname string, params map[string]string) (driver.Connector, error) {
+	driverContext, ok := drv.(driver.DriverContext)
+	if !ok {
+		return nil, fmt.Errorf("the database driver does not support connectors")
+	}
+
+	values := url.Values{"parseTime": {"true"}, "interpolateParams": {"true"}}
+	for key, val := range params {
+		values.Set(key, val)
+	}
+	// Let the driver parse the options, so that a bad one fails now rather than on every connection.
+	base, err := mysql.ParseDSN("/" + dbname + "?" + values.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("invalid database options: %w", err)
+	}
+	base.Net = "tcp"
+	base.Addr = net.JoinHostPort(hostname, strconv.Itoa(port))
+
+	return &credentialsConnector{driver: driverContext, credentials: credentials, base: base}, nil
+}
+
+// credentialsConnector opens connections with the current credentials.  It keeps the connector of the driver for the
+// last credentials it was given.
+type credentialsConnector struct {
+	driver      driver.DriverContext
+	credentials Credentials
+	base        *mysql.Config
+
+	mu        sync.Mutex
+	user      string
+	password  string
+	connector driver.Connector
+}
+
+func (c *credentialsConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	connector, err := c.currentConnector()
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *credentialsConnector) currentConnector() (driver.Connector, error) {
+	user, password, err := c.credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connector != nil && user == c.user && password == c.password {
+		return c.connector, nil
+	}
+
+	cfg := c.base.Clone()
+	cfg.User = user
+	cfg.Passwd = password
+	connector, err := c.driver.OpenConnector(cfg.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+	if c.connector != nil {
+		log.Printf("Database credentials changed, connecting as %s from now on", user)
+	}
+	c.user, c.password, c.connector = user, password, connector
+	return connector, nil
+}
+
+func (c *credentialsConnector) Driver() driver.Driver {
+	return c.driver.(driver.Driver)
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/unit"
+	"nationalparks-rest/pkg"
+	"time"
+)
+
+var (
+	meter = metric.Must(global.Meter(pkg.METER_NAME))
+
+	queryDuration = meter.NewFloat64Histogram("db.client.query.duration",
+		metric.WithDescription("Time taken by a query function, including scanning the rows"), metric.WithUnit(unit.Milliseconds))
+	queryRows = meter.NewInt64Histogram("db.client.query.rows",
+		metric.WithDescription("Rows returned by a query function"), metric.WithUnit(unit.Dimensionless))
+)
+
+// observeQuery records the time a query function has taken since start, labelled with the function's name.  Defer it
+// as the function starts.
+func observeQuery(ctx context.Context, function string, start time.Time) {
+	queryDuration.Record(ctx, float64(time.Since(start))/float64(time.Millisecond), attribute.String("db.function", function))
+}
+
+// observeRows records the number of rows a query function returned.
+func observeRows(ctx context.Context, function string, rows int) {
+	queryRows.Record(ctx, int64(rows), attribute.String("db.function", function))
+}
+
+// ObservePoolStats reports the connection pool statistics of db, and of its replicas, labelled with the node, whenever
+// metrics are collected.
+func ObservePoolStats(db *sql.DB) {
+	var open, inUse, idle metric.Int64GaugeObserver
+	var waitCount, waitDuration metric.Int64CounterObserver
+
+	observe := func(result metric.BatchObserverResult, node string, db *sql.DB) {
+		stats := db.Stats()
+		result.Observe([]attribute.KeyValue{attribute.String("db.node", node)},
+			open.Observation(int64(stats.OpenConnections)),
+			inUse.Observation(int64(stats.InUse)),
+			idle.Observation(int64(stats.Idle)),
+			waitCount.Observation(stats.WaitCount),
+			waitDuration.Observation(stats.WaitDuration.Milliseconds()),
+		)
+	}
+	batch := meter.NewBatchObserver(func(ctx context.Context, result metric.BatchObserverResult) {
+		observe(result, PrimaryNode, db)
+		for _, r := range replicas {
+			observe(result, r.address, r.db)
+		}
+	})
+	open = batch.NewInt64GaugeObserver("db.client.connections.open",
+		metric.WithDescription("Open connections, in use or idle"))
+	inUse = batch.NewInt64GaugeObserver("db.client.connections.in_use",
+		metric.WithDescription("Connections currently running a query"))
+	idle = batch.NewInt64GaugeObserver("db.client.connections.idle",
+		metric.WithDescription("Open connections waiting to be used"))
+	waitCount = batch.NewInt64CounterObserver("db.client.connections.wait_count",
+		metric.WithDescription("Times a query had to wait for a free connection"))
+	waitDuration = batch.NewInt64CounterObserver("db.client.connections.wait_duration",
+		metric.WithDescription("Total time queries waited for a free connection"), metric.WithUnit(unit.Milliseconds))
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"sort"
+	"strings"
+)
+
+// The NATIONAL_PARKS table itself is owned by the nationalparks-mysql project.  Tables that only this service uses are
+// created by the numbered scripts in migrations/, which are applied in file name order and recorded in
+// SCHEMA_MIGRATIONS so that each runs once.
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrate applies every migration that has not been applied yet.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "Migrate")
+	defer span.End()
+
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS SCHEMA_MIGRATIONS ("+
+		"VERSION VARCHAR(255) NOT NULL PRIMARY KEY, "+
+		"APPLIED_AT TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)")
+	if err != nil {
+		return fmt.Errorf("creating SCHEMA_MIGRATIONS: %w", err)
+	}
+
+	pending, err := PendingMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+	for _, version := range pending {
+		script, err := migrations.ReadFile("migrations/" + version)
+		if err != nil {
+			return err
+		}
+
+		// The driver runs one statement per call.  MySQL commits DDL implicitly, so the scripts are written to be
+		// safe to re-run if a migration fails halfway.
+		for _, statement := range strings.Split(string(script), ";") {
+			if strings.TrimSpace(stripComments(statement)) == "" {
+				continue
+			}
+			if _, err = db.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("migration %s: %w", version, err)
+			}
+		}
+		if _, err = db.ExecContext(ctx, "INSERT INTO SCHEMA_MIGRATIONS (VERSION) VALUES (?)", version); err != nil {
+			return fmt.Errorf("recording migration %s: %w", version, err)
+		}
+		log.Printf("Applied database migration %s", version)
+	}
+	return nil
+}
+
+// PendingMigrations returns the migrations that have not been applied yet, in the order they will be applied.
+func PendingMigrations(ctx context.Context, db *sql.DB) ([]string, error) {
+	entries, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT VERSION FROM SCHEMA_MIGRATIONS")
+	if err != nil {
+		return nil, fmt.Errorf("reading SCHEMA_MIGRATIONS: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err = rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	for _, entry := range entries {
+		if !applied[entry.Name()] {
+			pending = append(pending, entry.Name())
+		}
+	}
+	sort.Strings(pending)
+	return pending, nil
+}
+
+func stripComments(statement string) string {
+	var lines []string
+	for _, line := range strings.Split(statement, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
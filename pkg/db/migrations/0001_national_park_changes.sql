@@ -0,0 +1,16 @@
+-- Change log behind the /nationalparks/changes event stream.  IDs are handed out by NATIONAL_PARK_CHANGE_SEQUENCE
+-- rather than AUTO_INCREMENT, see recordChange.
+CREATE TABLE IF NOT EXISTS NATIONAL_PARK_CHANGES (
+    ID         BIGINT       NOT NULL PRIMARY KEY,
+    EVENT_TYPE VARCHAR(16)  NOT NULL,
+    PARK_ID    INT          NOT NULL,
+    PAYLOAD    TEXT         NOT NULL,
+    CREATED_AT TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS NATIONAL_PARK_CHANGE_SEQUENCE (
+    ID BIGINT NOT NULL
+);
+
+INSERT INTO NATIONAL_PARK_CHANGE_SEQUENCE (ID)
+SELECT 0 FROM DUAL WHERE NOT EXISTS (SELECT * FROM NATIONAL_PARK_CHANGE_SEQUENCE);
@@ -0,0 +1,25 @@
+-- Webhook subscriptions and the outbox of deliveries to them.  recordChange adds an outbox row per matching
+-- subscription in the same transaction as the change, so a change is never committed without its deliveries.
+-- Dispatchers claim deliveries with FOR UPDATE OF ... SKIP LOCKED, which requires MySQL 8.0 or later, see
+-- CheckServerVersion.  EVENT_TYPES holds the comma separated event types without duplicates, at most
+-- "created,updated,deleted".
+CREATE TABLE IF NOT EXISTS WEBHOOK_SUBSCRIPTIONS (
+    ID          BIGINT        NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    URL         VARCHAR(2048) NOT NULL,
+    EVENT_TYPES VARCHAR(64)   NOT NULL,
+    SECRET      VARCHAR(255)  NOT NULL,
+    CREATED_AT  DATETIME(6)   NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS WEBHOOK_OUTBOX (
+    ID              BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    SUBSCRIPTION_ID BIGINT       NOT NULL,
+    CHANGE_ID       BIGINT       NOT NULL,
+    STATUS          VARCHAR(16)  NOT NULL,
+    ATTEMPTS        INT          NOT NULL DEFAULT 0,
+    NEXT_ATTEMPT_AT DATETIME(6)  NOT NULL,
+    LAST_ERROR      TEXT         NULL,
+    DELIVERED_AT    DATETIME(6)  NULL,
+    INDEX WEBHOOK_OUTBOX_DUE (STATUS, NEXT_ATTEMPT_AT),
+    INDEX WEBHOOK_OUTBOX_SUBSCRIPTION (SUBSCRIPTION_ID)
+);
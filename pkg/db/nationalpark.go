@@ -3,51 +3,152 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"math"
+	"nationalparks-rest/pkg"
 	"strings"
+	"time"
 )
 
 type NationalPark struct {
-	Id           int     `json:"id"`
-	LocationNum  string  `json:"location_num"`
-	LocationName string  `json:"location_name"`
-	Address      string  `json:"address"`
-	City         string  `json:"city"`
-	State        string  `json:"state"`
-	ZipCode      int     `json:"zip_code"`
-	PhoneNum     string  `json:"phone_num"`
-	FaxNum       string  `json:"fax_num"`
-	Latitude     float32 `json:"latitude"`
-	Longitude    float32 `json:"longitude"`
+	Id           int     `json:"id" xml:"id"`
+	LocationNum  string  `json:"location_num" xml:"location_num"`
+	LocationName string  `json:"location_name" xml:"location_name"`
+	Address      string  `json:"address" xml:"address"`
+	City         string  `json:"city" xml:"city"`
+	State        string  `json:"state" xml:"state"`
+	ZipCode      int     `json:"zip_code" xml:"zip_code"`
+	PhoneNum     string  `json:"phone_num" xml:"phone_num"`
+	FaxNum       string  `json:"fax_num" xml:"fax_num"`
+	Latitude     float32 `json:"latitude" xml:"latitude"`
+	Longitude    float32 `json:"longitude" xml:"longitude"`
 }
 
+// NoLimit can be passed as the count to any of the list queries to return every matching row.
+const NoLimit = math.MaxInt64
+
+// DefaultCount is the page size of a park list when the request does not ask for one.  The REST, gRPC and GraphQL
+// APIs share it so that they return the same pages for the same request.
+const DefaultCount = 5
+
+// ParkOrder is a column the list queries can be sorted by.
+type ParkOrder string
+
+const (
+	OrderById      ParkOrder = "ID"
+	OrderByName    ParkOrder = "LOCATION_NAME"
+	OrderByCity    ParkOrder = "CITY"
+	OrderByState   ParkOrder = "STATE"
+	OrderByZipCode ParkOrder = "ZIP_CODE"
+)
+
+func (o ParkOrder) valid() bool {
+	switch o {
+	case OrderById, OrderByName, OrderByCity, OrderByState, OrderByZipCode:
+		return true
+	}
+	return false
+}
+
+// NationalParkVisitor is called once for every row produced by one of the DBVisit* queries.  Returning an error stops
+// the scan and the error is returned to the caller.
+type NationalParkVisitor func(np NationalPark) error
+
 func DBGetNationalParkById(ctx context.Context, db *sql.DB, id int) (NationalPark, error) {
 	// Create a child span.
 	tracer := otel.GetTracerProvider().Tracer("")
-	_, span := tracer.Start(ctx, "DBGetNationalParkById")
+	ctx, span := tracer.Start(ctx, "DBGetNationalParkById")
 	defer span.End()
+	defer observeQuery(ctx, "DBGetNationalParkById", time.Now())
+	defer pkg.TimerFromContext(ctx).Start(pkg.PhaseDB)()
 
 	var np = NationalPark{}
-	var row = db.QueryRowContext(ctx, "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE FROM NATIONAL_PARKS WHERE ID=?", id)
-	return np, row.Scan(&np.Id, &np.LocationNum, &np.LocationName, &np.Address, &np.City, &np.State, &np.ZipCode, &np.PhoneNum, &np.FaxNum, &np.Latitude, &np.Longitude)
+	err := runRead(ctx, db, func(ctx context.Context, db *sql.DB) error {
+		var row = db.QueryRowContext(ctx, "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE FROM NATIONAL_PARKS WHERE ID=?", id)
+		return row.Scan(&np.Id, &np.LocationNum, &np.LocationName, &np.Address, &np.City, &np.State, &np.ZipCode, &np.PhoneNum, &np.FaxNum, &np.Latitude, &np.Longitude)
+	})
+	return np, err
 }
 
 func DBGetNationalParkByName(ctx context.Context, db *sql.DB, name string) (NationalPark, error) {
 	// Create a child span.
 	tracer := otel.GetTracerProvider().Tracer("")
-	_, span := tracer.Start(ctx, "DBGetNationalParkByName")
+	ctx, span := tracer.Start(ctx, "DBGetNationalParkByName")
 	defer span.End()
+	defer observeQuery(ctx, "DBGetNationalParkByName", time.Now())
+	defer pkg.TimerFromContext(ctx).Start(pkg.PhaseDB)()
 
 	var np = NationalPark{}
-	var row = db.QueryRowContext(ctx, "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE FROM NATIONAL_PARKS WHERE LOCATION_NAME=?", name)
-	return np, row.Scan(&np.Id, &np.LocationNum, &np.LocationName, &np.Address, &np.City, &np.State, &np.ZipCode, &np.PhoneNum, &np.FaxNum, &np.Latitude, &np.Longitude)
+	err := runRead(ctx, db, func(ctx context.Context, db *sql.DB) error {
+		var row = db.QueryRowContext(ctx, "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE FROM NATIONAL_PARKS WHERE LOCATION_NAME=?", name)
+		return row.Scan(&np.Id, &np.LocationNum, &np.LocationName, &np.Address, &np.City, &np.State, &np.ZipCode, &np.PhoneNum, &np.FaxNum, &np.Latitude, &np.Longitude)
+	})
+	return np, err
 }
 
 func DBGetNationalParks(ctx context.Context, db *sql.DB, city string, state string, zipcode string, start int, count int) ([]NationalPark, error) {
 	// Create a child span.
 	tracer := otel.GetTracerProvider().Tracer("")
-	_, span := tracer.Start(ctx, "DBGetNationalParks")
+	newctx, span := tracer.Start(ctx, "DBGetNationalParks")
+	defer span.End()
+	defer observeQuery(ctx, "DBGetNationalParks", time.Now())
+
+	var nps []NationalPark
+	err := runRead(newctx, db, func(ctx context.Context, db *sql.DB) error {
+		rows, err := queryNationalParks(ctx, db, city, state, zipcode, "", start, count)
+		nps, err = processRows(ctx, "DBGetNationalParks", rows, err)
+		return err
+	})
+	return nps, err
+}
+
+// DBGetNationalParksOrdered is DBGetNationalParks with the results sorted by the given column.
+func DBGetNationalParksOrdered(ctx context.Context, db *sql.DB, city string, state string, zipcode string, order ParkOrder, descending bool, start int, count int) ([]NationalPark, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	newctx, span := tracer.Start(ctx, "DBGetNationalParksOrdered")
 	defer span.End()
+	defer observeQuery(ctx, "DBGetNationalParksOrdered", time.Now())
+
+	if !order.valid() {
+		return nil, fmt.Errorf("cannot order national parks by %q", order)
+	}
+	var orderBy = string(order)
+	if descending {
+		orderBy += " DESC"
+	}
+	span.SetAttributes(attribute.String("order-by", orderBy))
+
+	var nps []NationalPark
+	err := runRead(newctx, db, func(ctx context.Context, db *sql.DB) error {
+		rows, err := queryNationalParks(ctx, db, city, state, zipcode, orderBy, start, count)
+		nps, err = processRows(ctx, "DBGetNationalParksOrdered", rows, err)
+		return err
+	})
+	return nps, err
+}
+
+// DBVisitNationalParks runs the same query as DBGetNationalParks but hands each row to visit as soon as it is scanned
+// instead of collecting the whole result set in memory.
+func DBVisitNationalParks(ctx context.Context, db *sql.DB, city string, state string, zipcode string, start int, count int, visit NationalParkVisitor) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	newctx, span := tracer.Start(ctx, "DBVisitNationalParks")
+	defer span.End()
+	defer observeQuery(ctx, "DBVisitNationalParks", time.Now())
+
+	return runVisit(newctx, db, visit, func(ctx context.Context, db *sql.DB, visit NationalParkVisitor) error {
+		rows, err := queryNationalParks(ctx, db, city, state, zipcode, "", start, count)
+		return visitRows(ctx, "DBVisitNationalParks", rows, err, visit)
+	})
+}
+
+// queryNationalParks runs the filtered list query.  orderBy is spliced into the SQL, so it must only ever come from a
+// validated ParkOrder.
+func queryNationalParks(ctx context.Context, db *sql.DB, city string, state string, zipcode string, orderBy string, start int, count int) (*sql.Rows, error) {
+	defer pkg.TimerFromContext(ctx).Start(pkg.PhaseDB)()
 
 	if city == "" {
 		city = "%"
@@ -63,14 +164,47 @@ func DBGetNationalParks(ctx context.Context, db *sql.DB, city string, state stri
 		zipcode = "%"
 	}
 
-	const query = "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, " +
+	var query = "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, " +
 		"FAX_NUM, LATITUDE, LONGITUDE FROM NATIONAL_PARKS " +
-		"WHERE LOWER(CITY) LIKE ? AND LOWER(STATE) LIKE ? AND ZIP_CODE LIKE ? " +
-		"LIMIT ? OFFSET ?"
+		"WHERE LOWER(CITY) LIKE ? AND LOWER(STATE) LIKE ? AND ZIP_CODE LIKE ? "
+	if orderBy != "" {
+		query += "ORDER BY " + orderBy + " "
+	}
+	query += "LIMIT ? OFFSET ?"
+
+	return db.QueryContext(ctx, query, city, state, zipcode, count, start)
+}
 
-	rows, err := db.QueryContext(ctx, query, city, state, zipcode, count, start)
+// DBGetNationalParksByStates returns every park in any of the given states, ordered by state and id.  It lets callers
+// that need the parks of many states, like the GraphQL resolvers, load them with a single query.
+func DBGetNationalParksByStates(ctx context.Context, db *sql.DB, states []string) ([]NationalPark, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	newctx, span := tracer.Start(ctx, "DBGetNationalParksByStates")
+	defer span.End()
+	defer observeQuery(ctx, "DBGetNationalParksByStates", time.Now())
+	span.SetAttributes(attribute.Int("states", len(states)))
 
-	return processRows(ctx, rows, err)
+	if len(states) == 0 {
+		return []NationalPark{}, nil
+	}
+
+	var args = make([]interface{}, len(states))
+	for i, state := range states {
+		args[i] = state
+	}
+	var query = "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE " +
+		"FROM NATIONAL_PARKS WHERE STATE IN (?" + strings.Repeat(", ?", len(states)-1) + ") ORDER BY STATE, ID"
+
+	var nps []NationalPark
+	err := runRead(newctx, db, func(ctx context.Context, db *sql.DB) error {
+		stop := pkg.TimerFromContext(ctx).Start(pkg.PhaseDB)
+		rows, err := db.QueryContext(ctx, query, args...)
+		stop()
+		nps, err = processRows(ctx, "DBGetNationalParksByStates", rows, err)
+		return err
+	})
+	return nps, err
 }
 
 func DBGetNationalParksByCity(ctx context.Context, db *sql.DB, city string, start int, count int) ([]NationalPark, error) {
@@ -78,11 +212,36 @@ func DBGetNationalParksByCity(ctx context.Context, db *sql.DB, city string, star
 	tracer := otel.GetTracerProvider().Tracer("")
 	newctx, span := tracer.Start(ctx, "DBGetNationalParksByCity")
 	defer span.End()
+	defer observeQuery(ctx, "DBGetNationalParksByCity", time.Now())
 
-	rows, err := db.QueryContext(ctx, "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE "+
-		"FROM NATIONAL_PARKS WHERE CITY = ? LIMIT ? OFFSET ?", city, count, start)
+	var nps []NationalPark
+	err := runRead(newctx, db, func(ctx context.Context, db *sql.DB) error {
+		rows, err := queryNationalParksByCity(ctx, db, city, start, count)
+		nps, err = processRows(ctx, "DBGetNationalParksByCity", rows, err)
+		return err
+	})
+	return nps, err
+}
 
-	return processRows(newctx, rows, err)
+// DBVisitNationalParksByCity is the streaming counterpart of DBGetNationalParksByCity.
+func DBVisitNationalParksByCity(ctx context.Context, db *sql.DB, city string, start int, count int, visit NationalParkVisitor) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	newctx, span := tracer.Start(ctx, "DBVisitNationalParksByCity")
+	defer span.End()
+	defer observeQuery(ctx, "DBVisitNationalParksByCity", time.Now())
+
+	return runVisit(newctx, db, visit, func(ctx context.Context, db *sql.DB, visit NationalParkVisitor) error {
+		rows, err := queryNationalParksByCity(ctx, db, city, start, count)
+		return visitRows(ctx, "DBVisitNationalParksByCity", rows, err, visit)
+	})
+}
+
+func queryNationalParksByCity(ctx context.Context, db *sql.DB, city string, start int, count int) (*sql.Rows, error) {
+	defer pkg.TimerFromContext(ctx).Start(pkg.PhaseDB)()
+
+	return db.QueryContext(ctx, "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE "+
+		"FROM NATIONAL_PARKS WHERE CITY = ? LIMIT ? OFFSET ?", city, count, start)
 }
 
 func DBGetNationalParksByState(ctx context.Context, db *sql.DB, state string, start int, count int) ([]NationalPark, error) {
@@ -90,11 +249,36 @@ func DBGetNationalParksByState(ctx context.Context, db *sql.DB, state string, st
 	tracer := otel.GetTracerProvider().Tracer("")
 	newctx, span := tracer.Start(ctx, "DBGetNationalParksByState")
 	defer span.End()
+	defer observeQuery(ctx, "DBGetNationalParksByState", time.Now())
 
-	rows, err := db.QueryContext(ctx, "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE "+
-		"FROM NATIONAL_PARKS WHERE STATE = ? LIMIT ? OFFSET ?", state, count, start)
+	var nps []NationalPark
+	err := runRead(newctx, db, func(ctx context.Context, db *sql.DB) error {
+		rows, err := queryNationalParksByState(ctx, db, state, start, count)
+		nps, err = processRows(ctx, "DBGetNationalParksByState", rows, err)
+		return err
+	})
+	return nps, err
+}
 
-	return processRows(newctx, rows, err)
+// DBVisitNationalParksByState is the streaming counterpart of DBGetNationalParksByState.
+func DBVisitNationalParksByState(ctx context.Context, db *sql.DB, state string, start int, count int, visit NationalParkVisitor) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	newctx, span := tracer.Start(ctx, "DBVisitNationalParksByState")
+	defer span.End()
+	defer observeQuery(ctx, "DBVisitNationalParksByState", time.Now())
+
+	return runVisit(newctx, db, visit, func(ctx context.Context, db *sql.DB, visit NationalParkVisitor) error {
+		rows, err := queryNationalParksByState(ctx, db, state, start, count)
+		return visitRows(ctx, "DBVisitNationalParksByState", rows, err, visit)
+	})
+}
+
+func queryNationalParksByState(ctx context.Context, db *sql.DB, state string, start int, count int) (*sql.Rows, error) {
+	defer pkg.TimerFromContext(ctx).Start(pkg.PhaseDB)()
+
+	return db.QueryContext(ctx, "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE "+
+		"FROM NATIONAL_PARKS WHERE STATE = ? LIMIT ? OFFSET ?", state, count, start)
 }
 
 func DBGetNationalParksByZipCode(ctx context.Context, db *sql.DB, zipCode int, start int, count int) ([]NationalPark, error) {
@@ -102,14 +286,88 @@ func DBGetNationalParksByZipCode(ctx context.Context, db *sql.DB, zipCode int, s
 	tracer := otel.GetTracerProvider().Tracer("")
 	newctx, span := tracer.Start(ctx, "DBGetNationalParksByZipCode")
 	defer span.End()
+	defer observeQuery(ctx, "DBGetNationalParksByZipCode", time.Now())
+
+	var nps []NationalPark
+	err := runRead(newctx, db, func(ctx context.Context, db *sql.DB) error {
+		rows, err := queryNationalParksByZipCode(ctx, db, zipCode, start, count)
+		nps, err = processRows(ctx, "DBGetNationalParksByZipCode", rows, err)
+		return err
+	})
+	return nps, err
+}
+
+// DBVisitNationalParksByZipCode is the streaming counterpart of DBGetNationalParksByZipCode.
+func DBVisitNationalParksByZipCode(ctx context.Context, db *sql.DB, zipCode int, start int, count int, visit NationalParkVisitor) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	newctx, span := tracer.Start(ctx, "DBVisitNationalParksByZipCode")
+	defer span.End()
+	defer observeQuery(ctx, "DBVisitNationalParksByZipCode", time.Now())
+
+	return runVisit(newctx, db, visit, func(ctx context.Context, db *sql.DB, visit NationalParkVisitor) error {
+		rows, err := queryNationalParksByZipCode(ctx, db, zipCode, start, count)
+		return visitRows(ctx, "DBVisitNationalParksByZipCode", rows, err, visit)
+	})
+}
 
-	rows, err := db.QueryContext(ctx, "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE "+
+func queryNationalParksByZipCode(ctx context.Context, db *sql.DB, zipCode int, start int, count int) (*sql.Rows, error) {
+	defer pkg.TimerFromContext(ctx).Start(pkg.PhaseDB)()
+
+	return db.QueryContext(ctx, "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE "+
 		"FROM NATIONAL_PARKS WHERE ZIP_CODE = ? LIMIT ? OFFSET ?", zipCode, count, start)
+}
+
+// EarthRadiusMiles is used to turn the great circle angle between two coordinates into a distance.
+const EarthRadiusMiles = 3958.8
+
+// DefaultNearRadiusMiles is the search radius of a nearby park search when the request does not specify one.
+const DefaultNearRadiusMiles = 50
 
-	return processRows(newctx, rows, err)
+func DBGetNationalParksNear(ctx context.Context, db *sql.DB, latitude float64, longitude float64, radiusMiles float64, start int, count int) ([]NationalPark, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	newctx, span := tracer.Start(ctx, "DBGetNationalParksNear")
+	defer span.End()
+	defer observeQuery(ctx, "DBGetNationalParksNear", time.Now())
+
+	var nps []NationalPark
+	err := runRead(newctx, db, func(ctx context.Context, db *sql.DB) error {
+		rows, err := queryNationalParksNear(ctx, db, latitude, longitude, radiusMiles, start, count)
+		nps, err = processRows(ctx, "DBGetNationalParksNear", rows, err)
+		return err
+	})
+	return nps, err
 }
 
-func processRows(ctx context.Context, rows *sql.Rows, err error) ([]NationalPark, error) {
+// DBVisitNationalParksNear is the streaming counterpart of DBGetNationalParksNear.
+func DBVisitNationalParksNear(ctx context.Context, db *sql.DB, latitude float64, longitude float64, radiusMiles float64, start int, count int, visit NationalParkVisitor) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	newctx, span := tracer.Start(ctx, "DBVisitNationalParksNear")
+	defer span.End()
+	defer observeQuery(ctx, "DBVisitNationalParksNear", time.Now())
+
+	return runVisit(newctx, db, visit, func(ctx context.Context, db *sql.DB, visit NationalParkVisitor) error {
+		rows, err := queryNationalParksNear(ctx, db, latitude, longitude, radiusMiles, start, count)
+		return visitRows(ctx, "DBVisitNationalParksNear", rows, err, visit)
+	})
+}
+
+// queryNationalParksNear returns the parks within radiusMiles of the given coordinate, closest first.  Distances use
+// the spherical law of cosines, which is plenty accurate at the scale of park locations.
+func queryNationalParksNear(ctx context.Context, db *sql.DB, latitude float64, longitude float64, radiusMiles float64, start int, count int) (*sql.Rows, error) {
+	defer pkg.TimerFromContext(ctx).Start(pkg.PhaseDB)()
+
+	const query = "SELECT ID, LOCATION_NUM, LOCATION_NAME, ADDRESS, CITY, STATE, ZIP_CODE, PHONE_NUM, FAX_NUM, LATITUDE, LONGITUDE " +
+		"FROM (SELECT *, ? * ACOS(LEAST(1, COS(RADIANS(?)) * COS(RADIANS(LATITUDE)) * COS(RADIANS(LONGITUDE) - RADIANS(?)) " +
+		"+ SIN(RADIANS(?)) * SIN(RADIANS(LATITUDE)))) AS DISTANCE FROM NATIONAL_PARKS) AS P " +
+		"WHERE DISTANCE <= ? ORDER BY DISTANCE LIMIT ? OFFSET ?"
+
+	return db.QueryContext(ctx, query, EarthRadiusMiles, latitude, longitude, latitude, radiusMiles, count, start)
+}
+
+func processRows(ctx context.Context, function string, rows *sql.Rows, err error) ([]NationalPark, error) {
 	// Create a child span.
 	tracer := otel.GetTracerProvider().Tracer("")
 	_, span := tracer.Start(ctx, "processRows")
@@ -121,14 +379,61 @@ func processRows(ctx context.Context, rows *sql.Rows, err error) ([]NationalPark
 
 	defer rows.Close()
 
+	defer pkg.TimerFromContext(ctx).Start(pkg.PhaseScan)()
+
 	nationalParks := []NationalPark{}
 	for rows.Next() {
 		var np NationalPark
-		if err = rows.Scan(&np.Id, &np.LocationNum, &np.LocationName, &np.Address, &np.City, &np.State, &np.ZipCode, &np.PhoneNum, &np.FaxNum, &np.Latitude, &np.Longitude); err != nil {
+		if err = scanNationalPark(rows, &np); err != nil {
 			return nil, err
 		}
 		nationalParks = append(nationalParks, np)
 	}
+	observeRows(ctx, function, len(nationalParks))
+
+	return nationalParks, rows.Err()
+}
+
+// visitRows scans rows one at a time and passes each NationalPark to visit.  Scanning stops at the first error
+// returned by either the driver or visit.
+func visitRows(ctx context.Context, function string, rows *sql.Rows, err error, visit NationalParkVisitor) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	_, span := tracer.Start(ctx, "visitRows")
+	defer span.End()
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	// Time the scanning only, visit is timed by whoever it belongs to.
+	timer := pkg.TimerFromContext(ctx)
+	var n int
+	for {
+		stop := timer.Start(pkg.PhaseScan)
+		if !rows.Next() {
+			stop()
+			break
+		}
+		var np NationalPark
+		err = scanNationalPark(rows, &np)
+		stop()
+		if err != nil {
+			return err
+		}
+		if err = visit(np); err != nil {
+			return err
+		}
+		n++
+	}
+	span.SetAttributes(attribute.Int("rows", n))
+	observeRows(ctx, function, n)
+
+	return rows.Err()
+}
 
-	return nationalParks, nil
+func scanNationalPark(rows *sql.Rows, np *NationalPark) error {
+	return rows.Scan(&np.Id, &np.LocationNum, &np.LocationName, &np.Address, &np.City, &np.State, &np.ZipCode, &np.PhoneNum, &np.FaxNum, &np.Latitude, &np.Longitude)
 }
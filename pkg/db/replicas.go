@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicaPolicy says how reads are spread over the healthy replicas.
+type ReplicaPolicy string
+
+const (
+	// RoundRobin sends each read to the next replica in turn.
+	RoundRobin ReplicaPolicy = "round_robin"
+	// LeastConnections sends each read to the replica with the fewest connections in use.
+	LeastConnections ReplicaPolicy = "least_connections"
+)
+
+// PrimaryNode is what spans and metrics call the database passed to the DB* functions.
+const PrimaryNode = "primary"
+
+// Longest a replica health check may take.
+const replicaPingTimeout = 2 * time.Second
+
+var replicas []*replica
+var replicaPolicy = RoundRobin
+var nextReplica uint32
+
+// replica is a read replica, used for reads while healthy is 1.  It is -1 until the first health check.
+type replica struct {
+	address string
+	db      *sql.DB
+	healthy int32
+}
+
+// AddReplica adds a read replica of the primary, reached at address.  Reads go to the replicas that answered their last
+// health check, see CheckReplicas, and to the primary when there are none.
+func AddReplica(address string, db *sql.DB) {
+	replicas = append(replicas, &replica{address: address, db: db, healthy: -1})
+}
+
+// SetReplicaPolicy sets how reads are spread over the replicas.
+func SetReplicaPolicy(policy ReplicaPolicy) {
+	replicaPolicy = policy
+}
+
+// ParseReplicaPolicy checks that s names a ReplicaPolicy.
+func ParseReplicaPolicy(s string) (ReplicaPolicy, error) {
+	switch policy := ReplicaPolicy(s); policy {
+	case RoundRobin, LeastConnections:
+		return policy, nil
+	}
+	return "", fmt.Errorf("%q is not %s or %s", s, RoundRobin, LeastConnections)
+}
+
+// CheckReplicas pings every replica right away and then every interval until ctx is done.  Replicas that do not
+// answer get no reads until they do again.
+func CheckReplicas(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		var wg sync.WaitGroup
+		for _, r := range replicas {
+			wg.Add(1)
+			go func(r *replica) {
+				defer wg.Done()
+				pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+				defer cancel()
+				if err := r.db.PingContext(pingCtx); err != nil {
+					if ctx.Err() == nil {
+						r.setHealthy(false, err)
+					}
+					return
+				}
+				r.setHealthy(true, nil)
+			}(r)
+		}
+		wg.Wait()
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *replica) setHealthy(healthy bool, err error) {
+	if !healthy {
+		if atomic.SwapInt32(&r.healthy, 0) != 0 {
+			log.Warnf("Read replica %s is unhealthy, reading from the other nodes: %v", r.address, err)
+		}
+		return
+	}
+	if atomic.SwapInt32(&r.healthy, 1) != 1 {
+		log.Printf("Read replica %s is healthy, reading from it", r.address)
+	}
+}
+
+// readNode returns the database a read in ctx goes to, with its replica or nil for primary.  It is a replica chosen by
+// the replica policy, unless ctx is pinned to the primary or no replica is healthy.
+func readNode(ctx context.Context, primary *sql.DB) (*sql.DB, *replica) {
+	if len(replicas) == 0 || pinnedToPrimary(ctx) {
+		return primary, nil
+	}
+
+	var chosen *replica
+	switch replicaPolicy {
+	case LeastConnections:
+		inUse := 0
+		for _, r := range replicas {
+			if atomic.LoadInt32(&r.healthy) != 1 {
+				continue
+			}
+			if n := r.db.Stats().InUse; chosen == nil || n < inUse {
+				chosen, inUse = r, n
+			}
+		}
+	default:
+		start := atomic.AddUint32(&nextReplica, 1)
+		for i := range replicas {
+			r := replicas[(int(start)+i)%len(replicas)]
+			if atomic.LoadInt32(&r.healthy) == 1 {
+				chosen = r
+				break
+			}
+		}
+	}
+	if chosen == nil {
+		return primary, nil
+	}
+	return chosen.db, chosen
+}
+
+// recordNode notes on the span in ctx which node serves its query.
+func recordNode(ctx context.Context, r *replica) {
+	node := PrimaryNode
+	if r != nil {
+		node = r.address
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("db.node", node))
+}
+
+// session tracks the writes of a request, after which its reads must see them.
+type session struct {
+	pinned int32
+	wrote  int32
+}
+
+type sessionKey struct{}
+
+// TrackWrites returns a context in which a successful write sends the reads that follow it to the primary, and a
+// function reporting whether there was one.  Replicas lag behind the primary, so a read from one could miss the write.
+func TrackWrites(ctx context.Context) (context.Context, func() bool) {
+	s := &session{}
+	return context.WithValue(ctx, sessionKey{}, s), func() bool { return atomic.LoadInt32(&s.wrote) == 1 }
+}
+
+// PinToPrimary returns a context whose reads all go to the primary, such as those of a client that has just written.
+func PinToPrimary(ctx context.Context) context.Context {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	if !ok {
+		s = &session{}
+		ctx = context.WithValue(ctx, sessionKey{}, s)
+	}
+	atomic.StoreInt32(&s.pinned, 1)
+	return ctx
+}
+
+func pinnedToPrimary(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && atomic.LoadInt32(&s.pinned) == 1
+}
+
+// recordWrite pins the reads that follow a successful write in ctx to the primary.
+func recordWrite(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		atomic.StoreInt32(&s.wrote, 1)
+		atomic.StoreInt32(&s.pinned, 1)
+	}
+}
@@ -0,0 +1,260 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable is returned without querying the database while the circuit breaker is open, after the database has
+// failed too many times in a row.
+var ErrUnavailable = errors.New("the database is unavailable")
+
+// MySQL error numbers after which the same statement usually succeeds when run again.
+const (
+	mysqlLockWaitTimeout = 1205
+	mysqlDeadlock        = 1213
+)
+
+// Delay before the first retry of a read, doubled for every further retry.
+const readRetryBackoff = 50 * time.Millisecond
+
+var queryTimeout = 5 * time.Second
+var readRetries = 2
+var breaker = newCircuitBreaker(5, 10*time.Second)
+
+// SetQueryTimeout sets how long a single query, or a transaction, may take.  Zero leaves it to the caller's context.
+func SetQueryTimeout(timeout time.Duration) {
+	queryTimeout = timeout
+}
+
+// SetReadRetries sets how many times a read that failed with a transient error, such as a broken connection or a
+// deadlock, is tried again.
+func SetReadRetries(retries int) {
+	readRetries = retries
+}
+
+// SetCircuitBreaker makes queries fail with ErrUnavailable for cooldown once the database failed failures times in a
+// row.  A failures of zero disables the circuit breaker.
+func SetCircuitBreaker(failures int, cooldown time.Duration) {
+	breaker = newCircuitBreaker(failures, cooldown)
+}
+
+// BreakerRetryAfter returns how long until the circuit breaker lets a query through again, zero when it is closed.
+func BreakerRetryAfter() time.Duration {
+	return breaker.retryAfter()
+}
+
+// runRead runs read, which must be idempotent, on a replica of primary or on primary itself, with the query timeout,
+// and retries it on transient errors.
+func runRead(ctx context.Context, primary *sql.DB, read func(ctx context.Context, db *sql.DB) error) error {
+	for attempt := 0; ; attempt++ {
+		db, r := readNode(ctx, primary)
+		err := runQuery(ctx, r, queryTimeout, func(ctx context.Context) error {
+			return read(ctx, db)
+		})
+		if !retryRead(ctx, attempt, err) {
+			return err
+		}
+	}
+}
+
+// runVisit is runRead for the DBVisit* queries.  The query timeout only runs until the first row has been passed to
+// visit, the rest takes as long as visit does.  Retries also stop with the first row.
+func runVisit(ctx context.Context, primary *sql.DB, visit NationalParkVisitor, query func(ctx context.Context, db *sql.DB, visit NationalParkVisitor) error) error {
+	for attempt := 0; ; attempt++ {
+		var visited bool
+		var visitErr error
+		db, r := readNode(ctx, primary)
+		err := runQuery(ctx, r, 0, func(ctx context.Context) error {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			// stopDeadline reports whether it stopped the deadline before it passed.
+			stopDeadline := func() bool { return true }
+			if queryTimeout > 0 {
+				stopDeadline = time.AfterFunc(queryTimeout, cancel).Stop
+			}
+
+			err := query(ctx, db, func(np NationalPark) error {
+				if !visited {
+					stopDeadline()
+					visited = true
+				}
+				visitErr = visit(np)
+				return visitErr
+			})
+			if visitErr != nil {
+				// The reader failed, not the database.
+				return nil
+			}
+			if err != nil && !visited && !stopDeadline() {
+				return fmt.Errorf("no rows after %s: %w", queryTimeout, context.DeadlineExceeded)
+			}
+			return err
+		})
+		if visitErr != nil {
+			return visitErr
+		}
+		if visited || !retryRead(ctx, attempt, err) {
+			return err
+		}
+	}
+}
+
+// retryRead reports whether a read that failed with err should be tried again, after waiting for the backoff of the
+// attempt.
+func retryRead(ctx context.Context, attempt int, err error) bool {
+	if err == nil || attempt >= readRetries || !isTransient(err) || ctx.Err() != nil {
+		return false
+	}
+
+	trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt+1), attribute.String("error", err.Error())))
+	log.WithContext(ctx).Debugf("Retrying a read after a transient error: %v", err)
+	select {
+	case <-time.After(readRetryBackoff << attempt):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// runQuery runs query on the replica r, or on the primary when r is nil, with timeout.  Queries on the primary fail
+// right away while the circuit breaker is open, and report their outcome to it.  Replicas that fail are left out of
+// the reads until their next health check.  Writes go through it without retries, as they may have been applied before
+// the error.
+func runQuery(ctx context.Context, r *replica, timeout time.Duration, query func(ctx context.Context) error) error {
+	recordNode(ctx, r)
+	if r == nil && !breaker.allow() {
+		return ErrUnavailable
+	}
+
+	queryCtx, cancel := ctx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		queryCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	err := query(queryCtx)
+	timedOut := queryCtx.Err() == context.DeadlineExceeded
+	cancel()
+
+	// Only failures of the database itself count, not missing rows or a caller that went away.
+	if ctx.Err() != nil {
+		if r == nil {
+			breaker.abandon()
+		}
+		return err
+	}
+	failed := err != nil && (isTransient(err) || timedOut || errors.Is(err, context.DeadlineExceeded))
+	if r != nil {
+		if failed {
+			r.setHealthy(false, err)
+		}
+		return err
+	}
+	breaker.record(failed)
+	return err
+}
+
+// isTransient reports whether err is likely to go away when the statement is run again.
+func isTransient(err error) bool {
+	// A query that ran out of time would run out of time again.  context.DeadlineExceeded is a net.Error too.
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDeadlock || mysqlErr.Number == mysqlLockWaitTimeout
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// circuitBreaker opens after failures failed queries in a row, and then rejects queries for cooldown.  After that it
+// lets a single query through, which closes it again when it succeeds.
+type circuitBreaker struct {
+	failures int
+	cooldown time.Duration
+
+	mu          sync.Mutex
+	consecutive int
+	openUntil   time.Time
+	probing     bool
+}
+
+func newCircuitBreaker(failures int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{failures: failures, cooldown: cooldown}
+}
+
+func (b *circuitBreaker) allow() bool {
+	if b.failures <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.consecutive < b.failures {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *circuitBreaker) record(failed bool) {
+	if b.failures <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasOpen := b.consecutive >= b.failures
+	b.probing = false
+	if !failed {
+		if wasOpen {
+			log.Printf("Database circuit breaker closed, the database answers again")
+		}
+		b.consecutive = 0
+		return
+	}
+
+	b.consecutive++
+	if b.consecutive >= b.failures {
+		b.openUntil = time.Now().Add(b.cooldown)
+		if !wasOpen {
+			log.Warnf("Database circuit breaker opened after %d failures in a row, failing queries for %s", b.consecutive, b.cooldown)
+		}
+	}
+}
+
+// abandon lets another query probe the database when the one that was let through says nothing about it.
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+func (b *circuitBreaker) retryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures <= 0 || b.consecutive < b.failures {
+		return 0
+	}
+	if wait := time.Until(b.openUntil); wait > 0 {
+		return wait
+	}
+	return 0
+}
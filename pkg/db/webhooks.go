@@ -0,0 +1,314 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"strings"
+	"time"
+)
+
+// Statuses of a webhook delivery in the outbox.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookSubscription asks for the changes of the given event types to be POSTed to URL, signed with Secret.
+type WebhookSubscription struct {
+	Id         int64     `json:"id" xml:"id"`
+	URL        string    `json:"url" xml:"url"`
+	EventTypes []string  `json:"event_types" xml:"event_type"`
+	Secret     string    `json:"secret,omitempty" xml:"-"`
+	CreatedAt  time.Time `json:"created_at" xml:"created_at"`
+}
+
+// WebhookDelivery is an outbox entry: one change to be delivered to one subscription.
+type WebhookDelivery struct {
+	Id             int64              `json:"id" xml:"id"`
+	SubscriptionId int64              `json:"subscription_id" xml:"subscription_id"`
+	URL            string             `json:"url" xml:"url"`
+	Secret         string             `json:"-" xml:"-"`
+	Status         string             `json:"status" xml:"status"`
+	Attempts       int                `json:"attempts" xml:"attempts"`
+	NextAttemptAt  time.Time          `json:"next_attempt_at" xml:"next_attempt_at"`
+	LastError      string             `json:"last_error,omitempty" xml:"last_error,omitempty"`
+	Change         NationalParkChange `json:"change" xml:"-"`
+}
+
+func DBCreateWebhookSubscription(ctx context.Context, db *sql.DB, subscription WebhookSubscription) (WebhookSubscription, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBCreateWebhookSubscription")
+	defer span.End()
+
+	subscription.CreatedAt = time.Now().UTC()
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, "INSERT INTO WEBHOOK_SUBSCRIPTIONS (URL, EVENT_TYPES, SECRET, CREATED_AT) VALUES (?, ?, ?, ?)",
+			subscription.URL, strings.Join(subscription.EventTypes, ","), subscription.Secret, subscription.CreatedAt)
+		if err != nil {
+			return err
+		}
+		subscription.Id, err = result.LastInsertId()
+		return err
+	})
+	if err != nil {
+		return subscription, err
+	}
+	span.SetAttributes(attribute.Int64("id", subscription.Id))
+	return subscription, nil
+}
+
+func DBGetWebhookSubscriptions(ctx context.Context, db *sql.DB) ([]WebhookSubscription, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBGetWebhookSubscriptions")
+	defer span.End()
+	defer observeQuery(ctx, "DBGetWebhookSubscriptions", time.Now())
+
+	var subscriptions []WebhookSubscription
+	err := runRead(ctx, db, func(ctx context.Context, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, "SELECT ID, URL, EVENT_TYPES, SECRET, CREATED_AT FROM WEBHOOK_SUBSCRIPTIONS ORDER BY ID")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		subscriptions = []WebhookSubscription{}
+		for rows.Next() {
+			var subscription WebhookSubscription
+			if err = scanWebhookSubscription(rows, &subscription); err != nil {
+				return err
+			}
+			subscriptions = append(subscriptions, subscription)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	observeRows(ctx, "DBGetWebhookSubscriptions", len(subscriptions))
+	return subscriptions, nil
+}
+
+func DBGetWebhookSubscription(ctx context.Context, db *sql.DB, id int64) (WebhookSubscription, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBGetWebhookSubscription")
+	defer span.End()
+	defer observeQuery(ctx, "DBGetWebhookSubscription", time.Now())
+	span.SetAttributes(attribute.Int64("id", id))
+
+	var subscription WebhookSubscription
+	err := runRead(ctx, db, func(ctx context.Context, db *sql.DB) error {
+		var row = db.QueryRowContext(ctx, "SELECT ID, URL, EVENT_TYPES, SECRET, CREATED_AT FROM WEBHOOK_SUBSCRIPTIONS WHERE ID=?", id)
+		return scanWebhookSubscription(row, &subscription)
+	})
+	return subscription, err
+}
+
+// DBDeleteWebhookSubscription deletes a subscription together with its undelivered and dead deliveries.  It returns
+// sql.ErrNoRows if there is no such subscription.
+func DBDeleteWebhookSubscription(ctx context.Context, db *sql.DB, id int64) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBDeleteWebhookSubscription")
+	defer span.End()
+	span.SetAttributes(attribute.Int64("id", id))
+
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, "DELETE FROM WEBHOOK_SUBSCRIPTIONS WHERE ID=?", id)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return sql.ErrNoRows
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM WEBHOOK_OUTBOX WHERE SUBSCRIPTION_ID=?", id)
+		return err
+	})
+}
+
+// queueWebhookDeliveries adds an outbox entry for the change to every subscription to its event type, as part of the
+// transaction recording the change.
+func queueWebhookDeliveries(ctx context.Context, tx *sql.Tx, changeId int64, eventType string, now time.Time) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO WEBHOOK_OUTBOX (SUBSCRIPTION_ID, CHANGE_ID, STATUS, NEXT_ATTEMPT_AT) "+
+		"SELECT ID, ?, ?, ? FROM WEBHOOK_SUBSCRIPTIONS WHERE FIND_IN_SET(?, EVENT_TYPES) > 0",
+		changeId, DeliveryPending, now, eventType)
+	return err
+}
+
+const webhookDeliveryColumns = "O.ID, O.SUBSCRIPTION_ID, S.URL, S.SECRET, O.STATUS, O.ATTEMPTS, O.NEXT_ATTEMPT_AT, " +
+	"COALESCE(O.LAST_ERROR, ''), C.ID, C.EVENT_TYPE, C.CREATED_AT, C.PAYLOAD " +
+	"FROM WEBHOOK_OUTBOX O " +
+	"JOIN WEBHOOK_SUBSCRIPTIONS S ON S.ID = O.SUBSCRIPTION_ID " +
+	"JOIN NATIONAL_PARK_CHANGES C ON C.ID = O.CHANGE_ID "
+
+// DBClaimWebhookDeliveries returns up to count pending deliveries that are due, oldest first, and leases them by
+// moving their next attempt lease into the future.  Other dispatchers skip them until the lease runs out, so a
+// dispatcher that dies mid-delivery only delays the delivery instead of losing it.
+func DBClaimWebhookDeliveries(ctx context.Context, db *sql.DB, count int, lease time.Duration) ([]WebhookDelivery, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBClaimWebhookDeliveries")
+	defer span.End()
+
+	deliveries := []WebhookDelivery{}
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		now := time.Now().UTC()
+		rows, err := tx.QueryContext(ctx, "SELECT "+webhookDeliveryColumns+
+			"WHERE O.STATUS = ? AND O.NEXT_ATTEMPT_AT <= ? ORDER BY O.ID LIMIT ? FOR UPDATE OF O SKIP LOCKED",
+			DeliveryPending, now, count)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var ids []interface{}
+		for rows.Next() {
+			var delivery WebhookDelivery
+			if err = scanWebhookDelivery(rows, &delivery); err != nil {
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+			ids = append(ids, delivery.Id)
+		}
+		if err = rows.Err(); err != nil || len(ids) == 0 {
+			return err
+		}
+
+		args := append([]interface{}{now.Add(lease)}, ids...)
+		_, err = tx.ExecContext(ctx, "UPDATE WEBHOOK_OUTBOX SET NEXT_ATTEMPT_AT = ? "+
+			"WHERE ID IN (?"+strings.Repeat(", ?", len(ids)-1)+")", args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("rows", len(deliveries)))
+	return deliveries, nil
+}
+
+// DBCompleteWebhookDelivery marks a delivery as delivered.
+func DBCompleteWebhookDelivery(ctx context.Context, db *sql.DB, id int64, attempts int) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBCompleteWebhookDelivery")
+	defer span.End()
+	span.SetAttributes(attribute.Int64("id", id))
+
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE WEBHOOK_OUTBOX SET STATUS = ?, ATTEMPTS = ?, LAST_ERROR = NULL, DELIVERED_AT = ? WHERE ID = ?",
+			DeliveryDelivered, attempts, time.Now().UTC(), id)
+		return err
+	})
+}
+
+// DBFailWebhookDelivery records a failed attempt.  The delivery is retried at nextAttemptAt, or moved to the dead
+// letters if dead is set.
+func DBFailWebhookDelivery(ctx context.Context, db *sql.DB, id int64, attempts int, lastError string, nextAttemptAt time.Time, dead bool) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBFailWebhookDelivery")
+	defer span.End()
+	span.SetAttributes(attribute.Int64("id", id))
+
+	status := DeliveryPending
+	if dead {
+		status = DeliveryDead
+	}
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE WEBHOOK_OUTBOX SET STATUS = ?, ATTEMPTS = ?, LAST_ERROR = ?, NEXT_ATTEMPT_AT = ? WHERE ID = ?",
+			status, attempts, lastError, nextAttemptAt.UTC(), id)
+		return err
+	})
+}
+
+// DBGetDeadWebhookDeliveries returns the deliveries that ran out of attempts, oldest first.
+func DBGetDeadWebhookDeliveries(ctx context.Context, db *sql.DB, start int, count int) ([]WebhookDelivery, error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBGetDeadWebhookDeliveries")
+	defer span.End()
+	defer observeQuery(ctx, "DBGetDeadWebhookDeliveries", time.Now())
+
+	var deliveries []WebhookDelivery
+	err := runRead(ctx, db, func(ctx context.Context, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, "SELECT "+webhookDeliveryColumns+"WHERE O.STATUS = ? ORDER BY O.ID LIMIT ? OFFSET ?",
+			DeliveryDead, count, start)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		deliveries = []WebhookDelivery{}
+		for rows.Next() {
+			var delivery WebhookDelivery
+			if err = scanWebhookDelivery(rows, &delivery); err != nil {
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("rows", len(deliveries)))
+	observeRows(ctx, "DBGetDeadWebhookDeliveries", len(deliveries))
+	return deliveries, nil
+}
+
+// DBRetryWebhookDelivery moves a dead delivery back into the outbox with a fresh set of attempts.  It returns
+// sql.ErrNoRows if there is no such dead delivery.
+func DBRetryWebhookDelivery(ctx context.Context, db *sql.DB, id int64) error {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer("")
+	ctx, span := tracer.Start(ctx, "DBRetryWebhookDelivery")
+	defer span.End()
+	span.SetAttributes(attribute.Int64("id", id))
+
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, "UPDATE WEBHOOK_OUTBOX SET STATUS = ?, ATTEMPTS = 0, NEXT_ATTEMPT_AT = ? WHERE ID = ? AND STATUS = ?",
+			DeliveryPending, time.Now().UTC(), id, DeliveryDead)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhookSubscription(row scanner, subscription *WebhookSubscription) error {
+	var eventTypes string
+	if err := row.Scan(&subscription.Id, &subscription.URL, &eventTypes, &subscription.Secret, &subscription.CreatedAt); err != nil {
+		return err
+	}
+	subscription.EventTypes = strings.Split(eventTypes, ",")
+	return nil
+}
+
+func scanWebhookDelivery(row scanner, delivery *WebhookDelivery) error {
+	var payload []byte
+	err := row.Scan(&delivery.Id, &delivery.SubscriptionId, &delivery.URL, &delivery.Secret, &delivery.Status,
+		&delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastError,
+		&delivery.Change.Id, &delivery.Change.Type, &delivery.Change.Time, &payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, &delivery.Change.Park)
+}
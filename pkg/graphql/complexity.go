@@ -0,0 +1,113 @@
+package graphql
+
+import (
+	"fmt"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"math"
+	"nationalparks-rest/pkg/db"
+)
+
+// complexity estimates the number of objects a query can resolve before it is executed.  Every field costs one, and
+// the cost of a list field's selection is multiplied by its first argument, so that a few levels of nested sameState
+// lists add up the way their database and serialization work does.  The estimate stops growing once it passes limit,
+// as the query is rejected then anyway, so that huge queries cannot overflow it.
+func (h *Handler) complexity(req request, limit int) (int, []*errors.QueryError) {
+	doc, gqlErrs := gqlparser.LoadQuery(h.parsed, req.Query)
+	if len(gqlErrs) > 0 {
+		errs := make([]*errors.QueryError, 0, len(gqlErrs))
+		for _, gqlErr := range gqlErrs {
+			errs = append(errs, errors.Errorf("%s", gqlErr.Message))
+		}
+		return 0, errs
+	}
+
+	operation := doc.Operations.ForName(req.OperationName)
+	if operation == nil {
+		return 0, []*errors.QueryError{errors.Errorf("unknown operation %q", req.OperationName)}
+	}
+	cost, err := selectionComplexity(operation.SelectionSet, req.Variables, limit)
+	if err != nil {
+		return 0, []*errors.QueryError{errors.Errorf("%v", err)}
+	}
+	return cost, nil
+}
+
+func selectionComplexity(selections ast.SelectionSet, variables map[string]interface{}, limit int) (int, error) {
+	var cost int
+	for _, selection := range selections {
+		var selected int
+		var err error
+		switch s := selection.(type) {
+		case *ast.Field:
+			selected, err = selectionComplexity(s.SelectionSet, variables, limit)
+			if err == nil && s.Definition != nil && s.Definition.Type.Elem != nil {
+				var size int
+				if size, err = listSize(s, variables); err == nil {
+					selected = saturatingMul(selected, size, limit)
+				}
+			}
+			selected = saturatingAdd(selected, 1, limit)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				selected, err = selectionComplexity(s.Definition.SelectionSet, variables, limit)
+			}
+		case *ast.InlineFragment:
+			selected, err = selectionComplexity(s.SelectionSet, variables, limit)
+		}
+		if err != nil {
+			return 0, err
+		}
+		cost = saturatingAdd(cost, selected, limit)
+	}
+	return cost, nil
+}
+
+// listSize returns the first argument of a list field, falling back to the schema default.  It must be a page size
+// the resolvers accept, see checkFirst.
+func listSize(field *ast.Field, variables map[string]interface{}) (int, error) {
+	var value interface{}
+	if argument := field.Arguments.ForName("first"); argument != nil {
+		value, _ = argument.Value.Value(variables)
+	} else if definition := field.Definition.Arguments.ForName("first"); definition != nil && definition.DefaultValue != nil {
+		value, _ = definition.DefaultValue.Value(nil)
+	}
+
+	var first int64
+	switch n := value.(type) {
+	case int64:
+		first = n
+	case float64:
+		// Variables decoded from JSON.
+		if n != math.Trunc(n) || math.Abs(n) > math.MaxInt32 {
+			return 0, fmt.Errorf("%s: first must be a whole number, not %v", field.Alias, n)
+		}
+		first = int64(n)
+	case int:
+		first = int64(n)
+	default:
+		// The page size default lives in schema.graphql, matching db.DefaultCount.
+		return db.DefaultCount, nil
+	}
+	if err := checkFirst(first); err != nil {
+		return 0, fmt.Errorf("%s: %v", field.Alias, err)
+	}
+	return int(first), nil
+}
+
+// saturatingAdd returns a + b, or limit + 1 once that is exceeded.  a and b are not negative.
+func saturatingAdd(a int, b int, limit int) int {
+	if a > limit-b {
+		return limit + 1
+	}
+	return a + b
+}
+
+// saturatingMul returns a * b, or limit + 1 once that is exceeded.  a and b are not negative.
+func saturatingMul(a int, b int, limit int) int {
+	if b != 0 && a > limit/b {
+		return limit + 1
+	}
+	return a * b
+}
@@ -0,0 +1,106 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComplexity(t *testing.T) {
+	h, err := NewHandler(6, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		cost      int
+		err       string
+	}{
+		{
+			name:  "defaults",
+			query: `{ parks { id } }`,
+			cost:  1 + 5*1,
+		},
+		{
+			name:  "nested lists",
+			query: `{ parks(first: 10) { id sameState(first: 10) { id } } }`,
+			cost:  1 + 10*(1+1+10*1),
+		},
+		{
+			name:  "fragment",
+			query: `{ parks(first: 2) { ...names } } fragment names on Park { locationName city }`,
+			cost:  1 + 2*2,
+		},
+		{
+			name:  "past the limit",
+			query: `{ parks(first: 100) { sameState(first: 100) { sameState(first: 100) { sameState(first: 100) { id } } } } }`,
+			cost:  1001,
+		},
+		{
+			name:  "many siblings past the limit",
+			query: `{ a: parks(first: 100) { sameState(first: 100) { id } } b: parks(first: 100) { sameState(first: 100) { id } } }`,
+			cost:  1001,
+		},
+		{
+			name:  "huge first",
+			query: `{ parks(first: 2147483647) { sameState(first: 2147483647) { id } } }`,
+			err:   "first must be at most 100",
+		},
+		{
+			name:  "negative first",
+			query: `{ a: parks(first: 1) { id } b: parks(first: -2147483648) { id } }`,
+			err:   "b: first must not be negative",
+		},
+		{
+			name:      "huge variable",
+			query:     `query($n: Int) { parks(first: $n) { id } }`,
+			variables: map[string]interface{}{"n": 1e12},
+			err:       "first must be a whole number",
+		},
+		{
+			name:      "fractional variable",
+			query:     `query($n: Int) { parks(first: $n) { id } }`,
+			variables: map[string]interface{}{"n": 1.5},
+			err:       "first must be a whole number",
+		},
+		{
+			name:      "variable",
+			query:     `query($n: Int) { parks(first: $n) { id } }`,
+			variables: map[string]interface{}{"n": float64(20)},
+			cost:      1 + 20*1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cost, errs := h.complexity(request{Query: test.query, Variables: test.variables}, 1000)
+			if test.err != "" {
+				if len(errs) == 0 || !strings.Contains(errs[0].Message, test.err) {
+					t.Fatalf("got cost %d and errors %v, want an error containing %q", cost, errs, test.err)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if cost != test.cost {
+				t.Errorf("got cost %d, want %d", cost, test.cost)
+			}
+		})
+	}
+}
+
+func TestWindowEnforcesMaxFirst(t *testing.T) {
+	if _, _, err := window(maxFirst, 0); err != nil {
+		t.Errorf("window(%d, 0): %v", maxFirst, err)
+	}
+	for _, first := range []int32{-1, maxFirst + 1} {
+		if _, _, err := window(first, 0); err == nil {
+			t.Errorf("window(%d, 0) accepted it", first)
+		}
+	}
+	if _, _, err := window(1, -1); err == nil {
+		t.Error("window(1, -1) accepted a negative offset")
+	}
+}
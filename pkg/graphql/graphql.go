@@ -0,0 +1,104 @@
+// Package graphql serves the national parks as a GraphQL API using the same pkg/db queries as the REST API.
+package graphql
+
+import (
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"net/http"
+)
+
+//go:embed schema.graphql
+var schemaDocument string
+
+var dtb *sql.DB
+
+func SetDB(d *sql.DB) {
+	dtb = d
+}
+
+// Handler executes GraphQL queries sent as a GET with query, operationName and variables parameters, or as a POST
+// with the same fields in a JSON body.
+type Handler struct {
+	schema        *graphql.Schema
+	parsed        *ast.Schema
+	maxComplexity int
+}
+
+// NewHandler returns a Handler that rejects queries nested deeper than maxDepth or costing more than maxComplexity,
+// see complexity.  A limit of zero disables it.
+func NewHandler(maxDepth int, maxComplexity int) (*Handler, error) {
+	schema, err := graphql.ParseSchema(schemaDocument, &resolver{}, graphql.MaxDepth(maxDepth), graphql.Tracer(tracer{}))
+	if err != nil {
+		return nil, fmt.Errorf("invalid GraphQL schema: %w", err)
+	}
+	parsed, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: schemaDocument})
+	if gqlErr != nil {
+		return nil, fmt.Errorf("invalid GraphQL schema: %w", gqlErr)
+	}
+	return &Handler{schema: schema, parsed: parsed, maxComplexity: maxComplexity}, nil
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("GraphQL Handler called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				respond(w, http.StatusBadRequest, failure(fmt.Sprintf("invalid variables: %v", err)))
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respond(w, http.StatusBadRequest, failure(fmt.Sprintf("invalid request body: %v", err)))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		respond(w, http.StatusMethodNotAllowed, failure("only GET and POST are supported"))
+		return
+	}
+
+	if h.maxComplexity > 0 {
+		cost, err := h.complexity(req, h.maxComplexity)
+		if err != nil {
+			respond(w, http.StatusOK, &graphql.Response{Errors: err})
+			return
+		}
+		if cost > h.maxComplexity {
+			respond(w, http.StatusOK, failure(fmt.Sprintf("query complexity exceeds the limit of %d", h.maxComplexity)))
+			return
+		}
+	}
+
+	respond(w, http.StatusOK, h.schema.Exec(withStateCache(r.Context()), req.Query, req.OperationName, req.Variables))
+}
+
+func failure(message string) *graphql.Response {
+	return &graphql.Response{Errors: []*errors.QueryError{errors.Errorf("%s", message)}}
+}
+
+func respond(w http.ResponseWriter, status int, response *graphql.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Errorf("Failed to write GraphQL response: %v", err)
+	}
+}
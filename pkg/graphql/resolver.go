@@ -0,0 +1,250 @@
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"nationalparks-rest/pkg/db"
+	"sync"
+)
+
+// maxFirst is the largest page any list field returns, so that the complexity estimate bounds the work of a query.
+const maxFirst = 100
+
+var sortColumns = map[string]db.ParkOrder{
+	"ID":       db.OrderById,
+	"NAME":     db.OrderByName,
+	"CITY":     db.OrderByCity,
+	"STATE":    db.OrderByState,
+	"ZIP_CODE": db.OrderByZipCode,
+}
+
+type resolver struct{}
+
+type parkFilter struct {
+	City    *string
+	State   *string
+	ZipCode *string
+}
+
+type parkSort struct {
+	Field     string
+	Direction string
+}
+
+// checkFirst checks that the first argument of a list field is a page size between 0 and maxFirst.
+func checkFirst(first int64) error {
+	if first < 0 {
+		return fmt.Errorf("first must not be negative, not %d", first)
+	}
+	if first > maxFirst {
+		return fmt.Errorf("first must be at most %d, not %d", maxFirst, first)
+	}
+	return nil
+}
+
+// window returns the start and count of the page selected by the first and offset arguments.
+func window(first int32, offset int32) (int, int, error) {
+	if err := checkFirst(int64(first)); err != nil {
+		return 0, 0, err
+	}
+	if offset < 0 {
+		return 0, 0, fmt.Errorf("offset must not be negative, not %d", offset)
+	}
+	return int(offset), int(first), nil
+}
+
+func (r *resolver) Park(ctx context.Context, args struct{ Id int32 }) (*parkResolver, error) {
+	np, err := db.DBGetNationalParkById(ctx, dtb, int(args.Id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newParkResolvers([]db.NationalPark{np})[0], nil
+}
+
+func (r *resolver) Parks(ctx context.Context, args struct {
+	Filter *parkFilter
+	Sort   *parkSort
+	First  int32
+	Offset int32
+}) ([]*parkResolver, error) {
+	start, count, err := window(args.First, args.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	var city, state, zipcode string
+	if args.Filter != nil {
+		city, state, zipcode = value(args.Filter.City), value(args.Filter.State), value(args.Filter.ZipCode)
+	}
+
+	var nps []db.NationalPark
+	if args.Sort != nil {
+		descending := args.Sort.Direction == "DESC"
+		nps, err = db.DBGetNationalParksOrdered(ctx, dtb, city, state, zipcode, sortColumns[args.Sort.Field], descending, start, count)
+	} else {
+		nps, err = db.DBGetNationalParks(ctx, dtb, city, state, zipcode, start, count)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newParkResolvers(nps), nil
+}
+
+func (r *resolver) NearbyParks(ctx context.Context, args struct {
+	Latitude    float64
+	Longitude   float64
+	RadiusMiles float64
+	First       int32
+	Offset      int32
+}) ([]*parkResolver, error) {
+	if args.Latitude < -90 || args.Latitude > 90 {
+		return nil, fmt.Errorf("bad latitude: %v", args.Latitude)
+	}
+	if args.Longitude < -180 || args.Longitude > 180 {
+		return nil, fmt.Errorf("bad longitude: %v", args.Longitude)
+	}
+	radius := args.RadiusMiles
+	if radius <= 0 {
+		radius = db.DefaultNearRadiusMiles
+	}
+	start, count, err := window(args.First, args.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	nps, err := db.DBGetNationalParksNear(ctx, dtb, args.Latitude, args.Longitude, radius, start, count)
+	if err != nil {
+		return nil, err
+	}
+	return newParkResolvers(nps), nil
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// stateCache holds the parks of every state loaded while executing one query.  The parks of a sameState list are in
+// the state of their parent, so nested sameState lists find theirs here and cost no queries.
+type stateCache struct {
+	mu    sync.Mutex
+	parks map[string][]db.NationalPark
+}
+
+type stateCacheKey struct{}
+
+// withStateCache returns a context for executing one query, whose park lists share a stateCache.
+func withStateCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stateCacheKey{}, &stateCache{parks: make(map[string][]db.NationalPark)})
+}
+
+// stateLoader loads the parks of every state referenced by a list of sibling parks that is not in the query's
+// stateCache yet with a single query, the first time any of them resolves sameState.  Without it a list of n parks
+// selecting sameState would cost n queries, and every level of nested sameState lists n more.
+type stateLoader struct {
+	states []string
+	once   sync.Once
+	parks  map[string][]db.NationalPark
+	err    error
+}
+
+func (l *stateLoader) load(ctx context.Context, state string) ([]db.NationalPark, error) {
+	l.once.Do(func() {
+		cache, ok := ctx.Value(stateCacheKey{}).(*stateCache)
+		if !ok {
+			cache = &stateCache{parks: make(map[string][]db.NationalPark)}
+		}
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+
+		var missing []string
+		for _, s := range l.states {
+			if _, loaded := cache.parks[s]; !loaded {
+				missing = append(missing, s)
+			}
+		}
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int("batched-states", len(missing)))
+		if len(missing) > 0 {
+			var nps []db.NationalPark
+			if nps, l.err = db.DBGetNationalParksByStates(ctx, dtb, missing); l.err != nil {
+				return
+			}
+			for _, s := range missing {
+				cache.parks[s] = nil
+			}
+			for _, np := range nps {
+				cache.parks[np.State] = append(cache.parks[np.State], np)
+			}
+		}
+
+		l.parks = make(map[string][]db.NationalPark, len(l.states))
+		for _, s := range l.states {
+			l.parks[s] = cache.parks[s]
+		}
+	})
+	return l.parks[state], l.err
+}
+
+type parkResolver struct {
+	np     db.NationalPark
+	loader *stateLoader
+}
+
+// newParkResolvers wraps a list of parks, sharing one stateLoader between them.
+func newParkResolvers(nps []db.NationalPark) []*parkResolver {
+	loader := &stateLoader{}
+	seen := make(map[string]bool)
+	resolvers := make([]*parkResolver, 0, len(nps))
+	for _, np := range nps {
+		if !seen[np.State] {
+			seen[np.State] = true
+			loader.states = append(loader.states, np.State)
+		}
+		resolvers = append(resolvers, &parkResolver{np: np, loader: loader})
+	}
+	return resolvers
+}
+
+func (p *parkResolver) Id() int32            { return int32(p.np.Id) }
+func (p *parkResolver) LocationNum() string  { return p.np.LocationNum }
+func (p *parkResolver) LocationName() string { return p.np.LocationName }
+func (p *parkResolver) Address() string      { return p.np.Address }
+func (p *parkResolver) City() string         { return p.np.City }
+func (p *parkResolver) State() string        { return p.np.State }
+func (p *parkResolver) ZipCode() int32       { return int32(p.np.ZipCode) }
+func (p *parkResolver) PhoneNum() string     { return p.np.PhoneNum }
+func (p *parkResolver) FaxNum() string       { return p.np.FaxNum }
+func (p *parkResolver) Latitude() float64    { return float64(p.np.Latitude) }
+func (p *parkResolver) Longitude() float64   { return float64(p.np.Longitude) }
+
+func (p *parkResolver) SameState(ctx context.Context, args struct{ First int32 }) ([]*parkResolver, error) {
+	if err := checkFirst(int64(args.First)); err != nil {
+		return nil, err
+	}
+	count := int(args.First)
+
+	nps, err := p.loader.load(ctx, p.np.State)
+	if err != nil {
+		return nil, err
+	}
+
+	var others []db.NationalPark
+	for _, np := range nps {
+		if len(others) == count {
+			break
+		}
+		if np.Id != p.np.Id {
+			others = append(others, np)
+		}
+	}
+	return newParkResolvers(others), nil
+}
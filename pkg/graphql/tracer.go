@@ -0,0 +1,65 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/graph-gophers/graphql-go/trace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"nationalparks-rest/pkg"
+)
+
+// tracer reports a GraphQL request as an OpenTelemetry span with a child span for validation and for every resolver
+// that does real work.  Fields that just return a struct member are skipped, they would only add noise.
+type tracer struct{}
+
+func (tracer) TraceQuery(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, varTypes map[string]*introspection.Type) (context.Context, trace.TraceQueryFinishFunc) {
+	ctx, span := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME).Start(ctx, "GraphQL query")
+	span.SetAttributes(attribute.String("graphql.document", queryString))
+	if operationName != "" {
+		span.SetAttributes(attribute.String("graphql.operation.name", operationName))
+	}
+	return ctx, func(errs []*errors.QueryError) {
+		endSpan(span, errs)
+	}
+}
+
+func (tracer) TraceValidation(ctx context.Context) trace.TraceValidationFinishFunc {
+	_, span := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME).Start(ctx, "GraphQL validation")
+	return func(errs []*errors.QueryError) {
+		endSpan(span, errs)
+	}
+}
+
+func (tracer) TraceField(ctx context.Context, label string, typeName string, fieldName string, trivial bool, args map[string]interface{}) (context.Context, trace.TraceFieldFinishFunc) {
+	if trivial {
+		return ctx, func(*errors.QueryError) {}
+	}
+
+	ctx, span := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME).Start(ctx, typeName+"."+fieldName)
+	for name, value := range args {
+		span.SetAttributes(attribute.String("graphql.args."+name, fmt.Sprint(value)))
+	}
+	return ctx, func(err *errors.QueryError) {
+		if err != nil {
+			endSpan(span, []*errors.QueryError{err})
+		} else {
+			span.End()
+		}
+	}
+}
+
+func endSpan(span oteltrace.Span, errs []*errors.QueryError) {
+	if len(errs) > 0 {
+		msg := errs[0].Error()
+		if len(errs) > 1 {
+			msg += fmt.Sprintf(" (and %d more errors)", len(errs)-1)
+		}
+		span.SetStatus(codes.Error, msg)
+	}
+	span.End()
+}
@@ -0,0 +1,245 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ParkServiceClient is the client API for ParkService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ParkServiceClient interface {
+	// Get returns a single park by id or exact name.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*NationalPark, error)
+	// List streams parks as they are read from the database.
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (ParkService_ListClient, error)
+	// Search returns a page of parks filtered by city, state and zip code.
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*ParksResponse, error)
+	// Near returns a page of parks within a radius of a coordinate, closest first.
+	Near(ctx context.Context, in *NearRequest, opts ...grpc.CallOption) (*ParksResponse, error)
+}
+
+type parkServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewParkServiceClient(cc grpc.ClientConnInterface) ParkServiceClient {
+	return &parkServiceClient{cc}
+}
+
+func (c *parkServiceClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*NationalPark, error) {
+	out := new(NationalPark)
+	err := c.cc.Invoke(ctx, "/nationalparks.v1.ParkService/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *parkServiceClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (ParkService_ListClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ParkService_ServiceDesc.Streams[0], "/nationalparks.v1.ParkService/List", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &parkServiceListClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ParkService_ListClient interface {
+	Recv() (*NationalPark, error)
+	grpc.ClientStream
+}
+
+type parkServiceListClient struct {
+	grpc.ClientStream
+}
+
+func (x *parkServiceListClient) Recv() (*NationalPark, error) {
+	m := new(NationalPark)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *parkServiceClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*ParksResponse, error) {
+	out := new(ParksResponse)
+	err := c.cc.Invoke(ctx, "/nationalparks.v1.ParkService/Search", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *parkServiceClient) Near(ctx context.Context, in *NearRequest, opts ...grpc.CallOption) (*ParksResponse, error) {
+	out := new(ParksResponse)
+	err := c.cc.Invoke(ctx, "/nationalparks.v1.ParkService/Near", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ParkServiceServer is the server API for ParkService service.
+// All implementations must embed UnimplementedParkServiceServer
+// for forward compatibility
+type ParkServiceServer interface {
+	// Get returns a single park by id or exact name.
+	Get(context.Context, *GetRequest) (*NationalPark, error)
+	// List streams parks as they are read from the database.
+	List(*ListRequest, ParkService_ListServer) error
+	// Search returns a page of parks filtered by city, state and zip code.
+	Search(context.Context, *SearchRequest) (*ParksResponse, error)
+	// Near returns a page of parks within a radius of a coordinate, closest first.
+	Near(context.Context, *NearRequest) (*ParksResponse, error)
+	mustEmbedUnimplementedParkServiceServer()
+}
+
+// UnimplementedParkServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedParkServiceServer struct {
+}
+
+func (UnimplementedParkServiceServer) Get(context.Context, *GetRequest) (*NationalPark, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedParkServiceServer) List(*ListRequest, ParkService_ListServer) error {
+	return status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedParkServiceServer) Search(context.Context, *SearchRequest) (*ParksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedParkServiceServer) Near(context.Context, *NearRequest) (*ParksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Near not implemented")
+}
+func (UnimplementedParkServiceServer) mustEmbedUnimplementedParkServiceServer() {}
+
+// UnsafeParkServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ParkServiceServer will
+// result in compilation errors.
+type UnsafeParkServiceServer interface {
+	mustEmbedUnimplementedParkServiceServer()
+}
+
+func RegisterParkServiceServer(s grpc.ServiceRegistrar, srv ParkServiceServer) {
+	s.RegisterService(&ParkService_ServiceDesc, srv)
+}
+
+func _ParkService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ParkServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/nationalparks.v1.ParkService/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ParkServiceServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ParkService_List_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ParkServiceServer).List(m, &parkServiceListServer{stream})
+}
+
+type ParkService_ListServer interface {
+	Send(*NationalPark) error
+	grpc.ServerStream
+}
+
+type parkServiceListServer struct {
+	grpc.ServerStream
+}
+
+func (x *parkServiceListServer) Send(m *NationalPark) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ParkService_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ParkServiceServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/nationalparks.v1.ParkService/Search",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ParkServiceServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ParkService_Near_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NearRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ParkServiceServer).Near(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/nationalparks.v1.ParkService/Near",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ParkServiceServer).Near(ctx, req.(*NearRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ParkService_ServiceDesc is the grpc.ServiceDesc for ParkService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ParkService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "nationalparks.v1.ParkService",
+	HandlerType: (*ParkServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _ParkService_Get_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _ParkService_Search_Handler,
+		},
+		{
+			MethodName: "Near",
+			Handler:    _ParkService_Near_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "List",
+			Handler:       _ParkService_List_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "nationalparks/v1/nationalparks.proto",
+}
@@ -0,0 +1,193 @@
+// Package grpc serves the ParkService defined in proto/nationalparks/v1 using the same pkg/db queries as the REST API.
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"nationalparks-rest/pkg"
+	"nationalparks-rest/pkg/db"
+	"nationalparks-rest/pkg/grpc/pb"
+)
+
+var dtb *sql.DB
+
+func SetDB(d *sql.DB) {
+	dtb = d
+}
+
+// NewServer returns a gRPC server with the ParkService registered, instrumented with the OpenTelemetry interceptors
+// that mirror the otelmux middleware of the REST API.  Server reflection is enabled so tools like grpcurl work without
+// the .proto file.  opts are added to those, such as the credentials to serve TLS with.
+func NewServer(opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(append([]grpc.ServerOption{
+		grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()),
+	}, opts...)...)
+	pb.RegisterParkServiceServer(server, &parkService{})
+	reflection.Register(server)
+	return server
+}
+
+type parkService struct {
+	pb.UnimplementedParkServiceServer
+}
+
+func (s *parkService) Get(ctx context.Context, req *pb.GetRequest) (*pb.NationalPark, error) {
+	log.WithContext(ctx).Debugf("ParkService.Get() called")
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(ctx, "ParkService.Get")
+	defer span.End()
+
+	var np db.NationalPark
+	var err error
+	switch key := req.Key.(type) {
+	case *pb.GetRequest_Id:
+		span.SetAttributes(attribute.Int("id", int(key.Id)))
+		np, err = db.DBGetNationalParkById(ctx, dtb, int(key.Id))
+	case *pb.GetRequest_Name:
+		span.SetAttributes(attribute.String("park-name", key.Name))
+		np, err = db.DBGetNationalParkByName(ctx, dtb, key.Name)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "either id or name is required")
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(np), nil
+}
+
+func (s *parkService) List(req *pb.ListRequest, stream pb.ParkService_ListServer) error {
+	log.WithContext(stream.Context()).Debugf("ParkService.List() called")
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(stream.Context(), "ParkService.List")
+	defer span.End()
+
+	count := int(req.Count)
+	if count <= 0 {
+		count = db.NoLimit
+	}
+	span.SetAttributes(attribute.Int("start", int(req.Start)))
+	span.SetAttributes(attribute.Int("count", count))
+
+	err := db.DBVisitNationalParks(ctx, dtb, "", "", "", int(req.Start), count, func(np db.NationalPark) error {
+		// Send blocks while the client's flow control window is full, so slow clients slow the scan down rather
+		// than piling rows up in memory.
+		return stream.Send(toProto(np))
+	})
+	if err != nil {
+		return toStatus(err)
+	}
+	return nil
+}
+
+func (s *parkService) Search(ctx context.Context, req *pb.SearchRequest) (*pb.ParksResponse, error) {
+	log.WithContext(ctx).Debugf("ParkService.Search() called")
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(ctx, "ParkService.Search")
+	defer span.End()
+
+	count := int(req.Count)
+	if count <= 0 {
+		count = db.DefaultCount
+	}
+	span.SetAttributes(attribute.Int("start", int(req.Start)))
+	span.SetAttributes(attribute.Int("count", count))
+
+	nps, err := db.DBGetNationalParks(ctx, dtb, req.City, req.State, req.ZipCode, int(req.Start), count)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoResponse(nps), nil
+}
+
+func (s *parkService) Near(ctx context.Context, req *pb.NearRequest) (*pb.ParksResponse, error) {
+	log.WithContext(ctx).Debugf("ParkService.Near() called")
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(ctx, "ParkService.Near")
+	defer span.End()
+
+	if req.Latitude < -90 || req.Latitude > 90 {
+		return nil, status.Errorf(codes.InvalidArgument, "bad latitude: %v", req.Latitude)
+	}
+	if req.Longitude < -180 || req.Longitude > 180 {
+		return nil, status.Errorf(codes.InvalidArgument, "bad longitude: %v", req.Longitude)
+	}
+	radius := req.RadiusMiles
+	if radius <= 0 {
+		radius = db.DefaultNearRadiusMiles
+	}
+	count := int(req.Count)
+	if count <= 0 {
+		count = db.DefaultCount
+	}
+
+	span.SetAttributes(attribute.Float64("latitude", req.Latitude))
+	span.SetAttributes(attribute.Float64("longitude", req.Longitude))
+	span.SetAttributes(attribute.Float64("radius", radius))
+	span.SetAttributes(attribute.Int("start", int(req.Start)))
+	span.SetAttributes(attribute.Int("count", count))
+
+	nps, err := db.DBGetNationalParksNear(ctx, dtb, req.Latitude, req.Longitude, radius, int(req.Start), count)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoResponse(nps), nil
+}
+
+// toStatus maps database errors onto gRPC status codes the same way the REST API maps them onto HTTP statuses.
+func toStatus(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, db.ErrUnavailable) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func toProto(np db.NationalPark) *pb.NationalPark {
+	return &pb.NationalPark{
+		Id:           int32(np.Id),
+		LocationNum:  np.LocationNum,
+		LocationName: np.LocationName,
+		Address:      np.Address,
+		City:         np.City,
+		State:        np.State,
+		ZipCode:      int32(np.ZipCode),
+		PhoneNum:     np.PhoneNum,
+		FaxNum:       np.FaxNum,
+		Latitude:     np.Latitude,
+		Longitude:    np.Longitude,
+	}
+}
+
+func toProtoResponse(nps []db.NationalPark) *pb.ParksResponse {
+	response := &pb.ParksResponse{Parks: make([]*pb.NationalPark, 0, len(nps))}
+	for _, np := range nps {
+		response.Parks = append(response.Parks, toProto(np))
+	}
+	return response
+}
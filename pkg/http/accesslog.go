@@ -0,0 +1,129 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// accessLogDetails is filled in by AccessLogRoute, inside the router, for AccessLog, which wraps the router and
+// cannot see the matched route or the server span itself.
+type accessLogDetails struct {
+	route       string
+	spanContext trace.SpanContext
+}
+
+type accessLogKey struct{}
+
+// AccessLog is a middleware logging one line per request with its method, route template, status, size, duration,
+// client address and trace.  It must wrap the whole router so that requests matching no route are logged too, and the
+// router must use AccessLogRoute after the tracing middleware to report the route and trace.
+//
+// The client address is the peer address, unless the peer is one of trustedProxies.  Then it is the last address in
+// X-Forwarded-For that is not a trusted proxy, as everything to the left of that can be made up by the client.
+func AccessLog(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			details := &accessLogDetails{}
+			r = r.WithContext(context.WithValue(r.Context(), accessLogKey{}, details))
+
+			start := time.Now()
+			wrapped, m := wrapResponseWriter(w, nil)
+			next.ServeHTTP(wrapped, r)
+			duration := time.Since(start)
+
+			fields := log.Fields{
+				"method":      r.Method,
+				"route":       details.route,
+				"path":        r.URL.Path,
+				"status":      m.Code,
+				"bytes":       m.Written,
+				"duration_ms": float64(duration) / float64(time.Millisecond),
+				"client_ip":   clientIP(r, trustedProxies),
+				"user_agent":  r.Header.Get("User-Agent"),
+			}
+			if details.spanContext.IsValid() {
+				fields["trace_id"] = details.spanContext.TraceID().String()
+				fields["span_id"] = details.spanContext.SpanID().String()
+			}
+			log.WithFields(fields).Info("request")
+		})
+	}
+}
+
+// AccessLogRoute reports the matched route and the server span to AccessLog.
+func AccessLogRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if details, ok := r.Context().Value(accessLogKey{}).(*accessLogDetails); ok {
+			if route := mux.CurrentRoute(r); route != nil {
+				details.route, _ = route.GetPathTemplate()
+			}
+			details.spanContext = trace.SpanContextFromContext(r.Context())
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ParseTrustedProxies parses a list of IP addresses and CIDR ranges.
+func ParseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q, expected an IP address or CIDR range", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q, expected an IP address or CIDR range", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+
+	// Walk the proxies back from the peer until one is not trusted.
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0 && isTrustedProxy(client, trustedProxies); i-- {
+		if hop := strings.TrimSpace(forwarded[i]); hop != "" {
+			client = hop
+		}
+	}
+	return client
+}
+
+func isTrustedProxy(address string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
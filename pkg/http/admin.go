@@ -0,0 +1,69 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"nationalparks-rest/pkg"
+	"net/http"
+)
+
+var adminToken string
+
+// SetAdminToken sets the bearer token the admin endpoints that change the running service require.  Without a token
+// they are disabled.
+func SetAdminToken(token string) {
+	adminToken = token
+}
+
+// LogLevel is the body of the log level admin endpoints.
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+func RouteGetLogLevel(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteGetLogLevel() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteGetLogLevel")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	respondWithSuccess(ctx, LogLevel{Level: log.GetLevel().String()}, w)
+}
+
+// RouteSetLogLevel changes the log level until the service restarts or the level is changed again.
+func RouteSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteSetLogLevel() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteSetLogLevel")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	if !authorize(ctx, w, r, adminToken, "ADMIN_TOKEN") {
+		return
+	}
+
+	var body LogLevel
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondWithErrorStatus(ctx, http.StatusBadRequest, fmt.Errorf("invalid log level: %v", err), w)
+		return
+	}
+	level, err := log.ParseLevel(body.Level)
+	if err != nil {
+		respondWithErrorStatus(ctx, http.StatusBadRequest, err, w)
+		return
+	}
+	span.SetAttributes(attribute.String("level", level.String()))
+
+	previous := log.GetLevel()
+	log.SetLevel(level)
+	log.WithContext(ctx).Warnf("Log level changed from %s to %s", previous, level)
+
+	respondWithSuccess(ctx, LogLevel{Level: level.String()}, w)
+}
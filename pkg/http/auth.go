@@ -0,0 +1,36 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// authorize checks that r carries token as its bearer token.  Otherwise it responds with 403 Forbidden when token is
+// empty, as the endpoint is disabled until the setting named env is set, or with 401 Unauthorized, and returns false.
+func authorize(ctx context.Context, w http.ResponseWriter, r *http.Request, token string, env string) bool {
+	if token == "" {
+		respondWithErrorStatus(ctx, http.StatusForbidden, fmt.Errorf("this endpoint is disabled, set %s to enable it", env), w)
+		return false
+	}
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		respondWithErrorStatus(ctx, http.StatusUnauthorized, fmt.Errorf("a valid bearer token is required"), w)
+		return false
+	}
+	return true
+}
+
+// RequireToken is a middleware that only lets requests carrying token as their bearer token through, see authorize.
+func RequireToken(token string, env string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if authorize(r.Context(), w, r, token, env) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"nationalparks-rest/pkg"
+	"nationalparks-rest/pkg/db"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Number of change log entries read per query while a subscriber catches up.
+const changePageSize = 100
+
+// ChangeFeed streams the change log to subscribers as Server-Sent Events.
+//
+// Subscribers are never sent changes directly.  They are only woken up, and each then reads the log after the last
+// event it delivered at whatever pace its client accepts.  A slow client therefore costs nothing but its own
+// connection: writers never wait for it and nothing queues up in memory on its behalf.  Because every subscriber reads
+// from the persisted log, a reconnecting client resumes exactly where it left off by sending Last-Event-ID.
+type ChangeFeed struct {
+	pollInterval      time.Duration
+	heartbeatInterval time.Duration
+	writeTimeout      time.Duration
+
+	mu          sync.Mutex
+	subscribers map[chan struct{}]bool
+	latestId    int64
+
+	// Closed when Run returns, ending every stream.
+	stopped chan struct{}
+}
+
+var changeFeed *ChangeFeed
+
+// SetChangeFeed gives the mutation handlers the feed to wake up after a change.
+func SetChangeFeed(f *ChangeFeed) {
+	changeFeed = f
+}
+
+// NewChangeFeed returns a ChangeFeed that checks the change log for changes made by other instances every
+// pollInterval and sends a comment at least every heartbeatInterval so proxies keep idle streams open.  Streams replace
+// the server's write timeout, which would cut them off, with writeTimeout for every write, so that a client that stops
+// reading still ends its stream.
+func NewChangeFeed(pollInterval time.Duration, heartbeatInterval time.Duration, writeTimeout time.Duration) *ChangeFeed {
+	return &ChangeFeed{
+		pollInterval:      pollInterval,
+		heartbeatInterval: heartbeatInterval,
+		writeTimeout:      writeTimeout,
+		subscribers:       make(map[chan struct{}]bool),
+		stopped:           make(chan struct{}),
+	}
+}
+
+// Run polls the change log until ctx is cancelled, waking the subscribers whenever it has grown.  Open streams end
+// when it returns, so that they do not hold up a server shutdown; their clients reconnect to another instance.
+func (f *ChangeFeed) Run(ctx context.Context) {
+	defer close(f.stopped)
+
+	ticker := time.NewTicker(f.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		f.mu.Lock()
+		idle := len(f.subscribers) == 0
+		f.mu.Unlock()
+		if idle {
+			continue
+		}
+
+		latestId, err := db.DBGetLatestNationalParkChangeId(ctx, dtb)
+		if err != nil {
+			log.Warnf("Failed to poll the national park change log: %v", err)
+			continue
+		}
+
+		f.mu.Lock()
+		if latestId > f.latestId {
+			f.latestId = latestId
+			f.mu.Unlock()
+			f.Notify()
+		} else {
+			f.mu.Unlock()
+		}
+	}
+}
+
+// Notify wakes every subscriber without waiting for the next poll.  It never blocks.
+func (f *ChangeFeed) Notify() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for wake := range f.subscribers {
+		select {
+		case wake <- struct{}{}:
+		default:
+			// Already woken and not yet caught up, it will read this change too.
+		}
+	}
+}
+
+func (f *ChangeFeed) subscribe() chan struct{} {
+	wake := make(chan struct{}, 1)
+	f.mu.Lock()
+	f.subscribers[wake] = true
+	f.mu.Unlock()
+	return wake
+}
+
+func (f *ChangeFeed) unsubscribe(wake chan struct{}) {
+	f.mu.Lock()
+	delete(f.subscribers, wake)
+	f.mu.Unlock()
+}
+
+// notifyChange wakes the change feed subscribers and the webhook dispatcher, if there are any.
+func notifyChange() {
+	if changeFeed != nil {
+		changeFeed.Notify()
+	}
+	if webhookDispatcher != nil {
+		webhookDispatcher.Notify()
+	}
+}
+
+// ServeHTTP streams the change log.  Without a Last-Event-ID header or lastEventId query parameter (EventSource cannot
+// set headers on its first request) only changes made after the request are sent.
+func (f *ChangeFeed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteGetNationalParkChanges() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteGetNationalParkChanges")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithErrorStatus(ctx, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"), w)
+		return
+	}
+
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get("lastEventId")
+	}
+	var lastId int64
+	var err error
+	if lastEventId != "" {
+		if lastId, err = strconv.ParseInt(lastEventId, 10, 64); err != nil || lastId < 0 {
+			respondWithErrorStatus(ctx, http.StatusBadRequest, fmt.Errorf("bad Last-Event-ID: %q", lastEventId), w)
+			return
+		}
+	} else if lastId, err = db.DBGetLatestNationalParkChangeId(ctx, dtb); err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+	span.SetAttributes(attribute.Int64("last-event-id", lastId))
+
+	// The server's write deadline counts from the start of the request, move it forward before every write instead.
+	// Writers that cannot, such as h2c streams, end at the server's write timeout and the client resumes.
+	controller := http.NewResponseController(w)
+	extendDeadline := func() {
+		if err := controller.SetWriteDeadline(time.Now().Add(f.writeTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.WithContext(ctx).Debugf("Failed to extend the change stream write deadline: %v", err)
+		}
+	}
+	extendDeadline()
+
+	// Subscribe before the first read so a change committed in between still wakes us.
+	wake := f.subscribe()
+	defer f.unsubscribe(wake)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Stop nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", time.Second.Milliseconds())
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(f.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	var sent int
+	defer func() {
+		span.SetAttributes(attribute.Int("events", sent))
+	}()
+
+	catchUp := true
+	for {
+		// Catch up with everything committed since the last event sent.
+		for catchUp {
+			changes, err := db.DBGetNationalParkChanges(ctx, dtb, lastId, changePageSize)
+			if err != nil {
+				// The client reconnects and resumes from the last event it received.
+				span.RecordError(err)
+				log.WithContext(ctx).Warnf("Failed to read the national park change log: %v", err)
+				return
+			}
+			for _, change := range changes {
+				extendDeadline()
+				if err = writeChangeEvent(w, change); err != nil {
+					return
+				}
+				lastId = change.Id
+				sent++
+			}
+			flusher.Flush()
+			catchUp = len(changes) == changePageSize
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-f.stopped:
+			return
+		case <-wake:
+			catchUp = true
+		case <-heartbeat.C:
+			extendDeadline()
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeChangeEvent(w http.ResponseWriter, change db.NationalParkChange) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.Id, change.Type, data)
+	return err
+}
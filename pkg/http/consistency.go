@@ -0,0 +1,46 @@
+package http
+
+import (
+	"nationalparks-rest/pkg/db"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// primaryCookie holds the Unix time until which the reads of a client that wrote go to the primary database.
+const primaryCookie = "read-primary-until"
+
+// ReadYourWrites is a middleware sending reads to the primary database instead of a read replica for window after a
+// client wrote, long enough for the replicas to catch up.  It sets a cookie on responses to requests that wrote, and
+// reads that come with it go to the primary.  Reads after a write in the same request always go to the primary.
+func ReadYourWrites(window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, wrote := db.TrackWrites(r.Context())
+			// The client can set the cookie to any time, so one later than a write now would set is ignored rather
+			// than sending its reads to the primary for good.
+			if cookie, err := r.Cookie(primaryCookie); err == nil {
+				now := time.Now()
+				until, err := strconv.ParseInt(cookie.Value, 10, 64)
+				if err == nil && now.Unix() < until && until <= now.Add(window).Unix() {
+					ctx = db.PinToPrimary(ctx)
+				}
+			}
+
+			// The cookie has to be set before the header is sent.
+			hooked, _ := wrapResponseWriter(w, func() {
+				if wrote() {
+					http.SetCookie(w, &http.Cookie{
+						Name:     primaryCookie,
+						Value:    strconv.FormatInt(time.Now().Add(window).Unix(), 10),
+						Path:     "/",
+						MaxAge:   int((window + time.Second - 1) / time.Second),
+						HttpOnly: true,
+						SameSite: http.SameSiteLaxMode,
+					})
+				}
+			})
+			next.ServeHTTP(hooked, r.WithContext(ctx))
+		})
+	}
+}
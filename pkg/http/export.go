@@ -0,0 +1,208 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"io"
+	"nationalparks-rest/pkg"
+	"nationalparks-rest/pkg/db"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// listPaging returns the start and count of a park list request.  Streaming representations are exports, so without
+// an explicit count they return every matching row; everything else defaults to a page of db.DefaultCount.  Negative values are
+// a bad request, see respondWithError.
+func listPaging(ctx context.Context, r *http.Request) (start int, count int, err error) {
+	start, err = strconv.Atoi(r.FormValue("start"))
+	if err != nil {
+		start = 0
+	}
+	count, err = strconv.Atoi(r.FormValue("count"))
+	if err != nil {
+		if representationFromContext(ctx).NewParkWriter != nil {
+			count = db.NoLimit
+		} else {
+			count = db.DefaultCount
+		}
+	}
+
+	if start < 0 {
+		return 0, 0, badRequest(fmt.Errorf("start must not be negative, not %d", start))
+	}
+	if count < 0 {
+		return 0, 0, badRequest(fmt.Errorf("count must not be negative, not %d", count))
+	}
+	return start, count, nil
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// exportFilename builds a Content-Disposition friendly file name out of the supplied parts.
+func exportFilename(parts ...string) string {
+	var cleaned []string
+	for _, part := range parts {
+		part = strings.Trim(unsafeFilenameChars.ReplaceAllString(part, "_"), "_")
+		if part != "" {
+			cleaned = append(cleaned, strings.ToLower(part))
+		}
+	}
+	return strings.Join(cleaned, "-")
+}
+
+// setContentDisposition marks the response as a download when the negotiated representation is a file format.
+func setContentDisposition(rep *Representation, filename string, w http.ResponseWriter) {
+	if rep.Extension != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", filename, rep.Extension))
+	}
+}
+
+// respondWithPark sends a single park in the negotiated representation.
+func respondWithPark(ctx context.Context, np db.NationalPark, filename string, w http.ResponseWriter) {
+	setContentDisposition(representationFromContext(ctx), filename, w)
+	respondWithSuccess(ctx, np, w)
+}
+
+// respondWithParks sends the parks produced by query in the negotiated representation.  Representations with a
+// ParkWriter receive the rows as they are scanned; the rest get the collected slice.
+func respondWithParks(ctx context.Context, filename string, w http.ResponseWriter, query func(visit db.NationalParkVisitor) error) {
+	rep := representationFromContext(ctx)
+	if rep.NewParkWriter != nil {
+		respondWithExport(ctx, rep, filename, w, query)
+		return
+	}
+
+	nps := []db.NationalPark{}
+	err := query(func(np db.NationalPark) error {
+		nps = append(nps, np)
+		return nil
+	})
+	if err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+	setContentDisposition(rep, filename, w)
+	respondWithSuccess(ctx, nps, w)
+}
+
+// respondWithExport streams the rows produced by query to w through the representation's ParkWriter.  Nothing is
+// written until the first row arrives so that a failing query can still be reported with a proper error status.
+func respondWithExport(ctx context.Context, rep *Representation, filename string, w http.ResponseWriter, query func(visit db.NationalParkVisitor) error) {
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	_, span := tracer.Start(ctx, "respondWithExport")
+	defer span.End()
+	span.SetAttributes(attribute.String("content-type", rep.MediaType))
+
+	timer := pkg.TimerFromContext(ctx)
+	var pw ParkWriter
+	begin := func() error {
+		w.Header().Set("Content-Type", rep.MediaType)
+		setContentDisposition(rep, filename, w)
+		if timer != nil {
+			// The Server-Timing header goes out with the first row, the phases of the whole export follow in a
+			// trailer.  Trailers are only sent with chunked responses, which announcing one before the header ensures.
+			w.Header().Set(http.TrailerPrefix+"Server-Timing", "")
+		}
+		w.WriteHeader(http.StatusOK)
+		pw = rep.NewParkWriter(w)
+		return pw.Begin()
+	}
+
+	var rows int
+	err := query(func(np db.NationalPark) error {
+		if pw == nil {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		rows++
+		defer timer.Start(pkg.PhaseSerialize)()
+		return pw.Write(np)
+	})
+
+	if err == nil && pw == nil {
+		err = begin()
+	}
+	if err == nil {
+		err = pw.End()
+	}
+	span.SetAttributes(attribute.Int("rows", rows))
+	if pw != nil && timer != nil {
+		w.Header().Set(http.TrailerPrefix+"Server-Timing", timer.ServerTiming())
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		if pw == nil {
+			respondWithError(ctx, err, w)
+			return
+		}
+		// The status line has already been sent, so all we can do is log and cut the response short.
+		log.WithContext(ctx).Errorf("export to %s aborted after %d rows: %v", rep.MediaType, rows, err)
+	}
+}
+
+var csvHeader = []string{"id", "location_num", "location_name", "address", "city", "state", "zip_code", "phone_num",
+	"fax_num", "latitude", "longitude"}
+
+type csvParkWriter struct {
+	w *csv.Writer
+}
+
+func newCSVParkWriter(w io.Writer) ParkWriter {
+	return &csvParkWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvParkWriter) Begin() error {
+	return c.w.Write(csvHeader)
+}
+
+func (c *csvParkWriter) Write(np db.NationalPark) error {
+	return c.w.Write([]string{
+		strconv.Itoa(np.Id),
+		np.LocationNum,
+		np.LocationName,
+		np.Address,
+		np.City,
+		np.State,
+		strconv.Itoa(np.ZipCode),
+		np.PhoneNum,
+		np.FaxNum,
+		strconv.FormatFloat(float64(np.Latitude), 'f', -1, 32),
+		strconv.FormatFloat(float64(np.Longitude), 'f', -1, 32),
+	})
+}
+
+func (c *csvParkWriter) End() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonParkWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONParkWriter(w io.Writer) ParkWriter {
+	return &ndjsonParkWriter{enc: json.NewEncoder(w)}
+}
+
+func (n *ndjsonParkWriter) Begin() error {
+	return nil
+}
+
+// Write relies on json.Encoder terminating every value with a newline, which is exactly the NDJSON framing.
+func (n *ndjsonParkWriter) Write(np db.NationalPark) error {
+	return n.enc.Encode(np)
+}
+
+func (n *ndjsonParkWriter) End() error {
+	return nil
+}
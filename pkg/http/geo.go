@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"nationalparks-rest/pkg/db"
+	"strconv"
+	"strings"
+)
+
+// parkDescription is the human readable text shown for a park in Google Earth and on GPS units.
+func parkDescription(np db.NationalPark) string {
+	var lines []string
+	if np.Address != "" {
+		lines = append(lines, np.Address)
+	}
+	lines = append(lines, fmt.Sprintf("%s, %s %05d", np.City, np.State, np.ZipCode))
+	if np.PhoneNum != "" {
+		lines = append(lines, "Phone: "+np.PhoneNum)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatCoordinate(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
+
+// xmlParkWriter holds the boilerplate shared by the XML based formats: the document prolog, a fixed set of wrapping
+// elements that are opened in Begin and closed in End, and one element per park in between.
+type xmlParkWriter struct {
+	w       io.Writer
+	enc     *xml.Encoder
+	wrap    []xml.StartElement
+	element func(np db.NationalPark) interface{}
+}
+
+func (x *xmlParkWriter) Begin() error {
+	if _, err := io.WriteString(x.w, xml.Header); err != nil {
+		return err
+	}
+	for _, start := range x.wrap {
+		if err := x.enc.EncodeToken(start); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *xmlParkWriter) Write(np db.NationalPark) error {
+	return x.enc.Encode(x.element(np))
+}
+
+func (x *xmlParkWriter) End() error {
+	for i := len(x.wrap) - 1; i >= 0; i-- {
+		if err := x.enc.EncodeToken(x.wrap[i].End()); err != nil {
+			return err
+		}
+	}
+	return x.enc.Flush()
+}
+
+// KML 2.2, see https://developers.google.com/kml/documentation/kmlreference
+type kmlPlacemark struct {
+	XMLName     xml.Name `xml:"Placemark"`
+	Id          string   `xml:"id,attr"`
+	Name        string   `xml:"name"`
+	Address     string   `xml:"address,omitempty"`
+	Phone       string   `xml:"phoneNumber,omitempty"`
+	Description string   `xml:"description"`
+	Coordinates string   `xml:"Point>coordinates"`
+}
+
+func newKMLParkWriter(w io.Writer) ParkWriter {
+	return &xmlParkWriter{
+		w:   w,
+		enc: xml.NewEncoder(w),
+		wrap: []xml.StartElement{
+			{Name: xml.Name{Local: "kml"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: "http://www.opengis.net/kml/2.2"}}},
+			{Name: xml.Name{Local: "Document"}},
+		},
+		element: func(np db.NationalPark) interface{} {
+			return kmlPlacemark{
+				Id:          fmt.Sprintf("park-%d", np.Id),
+				Name:        np.LocationName,
+				Address:     np.Address,
+				Phone:       np.PhoneNum,
+				Description: parkDescription(np),
+				// KML lists longitude first.
+				Coordinates: formatCoordinate(np.Longitude) + "," + formatCoordinate(np.Latitude),
+			}
+		},
+	}
+}
+
+// GPX 1.1, see https://www.topografix.com/GPX/1/1/
+type gpxWaypoint struct {
+	XMLName     xml.Name `xml:"wpt"`
+	Latitude    string   `xml:"lat,attr"`
+	Longitude   string   `xml:"lon,attr"`
+	Name        string   `xml:"name"`
+	Description string   `xml:"desc"`
+	Type        string   `xml:"type"`
+}
+
+func newGPXParkWriter(w io.Writer) ParkWriter {
+	return &xmlParkWriter{
+		w:   w,
+		enc: xml.NewEncoder(w),
+		wrap: []xml.StartElement{
+			{Name: xml.Name{Local: "gpx"}, Attr: []xml.Attr{
+				{Name: xml.Name{Local: "xmlns"}, Value: "http://www.topografix.com/GPX/1/1"},
+				{Name: xml.Name{Local: "version"}, Value: "1.1"},
+				{Name: xml.Name{Local: "creator"}, Value: "nationalparks-rest"},
+			}},
+		},
+		element: func(np db.NationalPark) interface{} {
+			return gpxWaypoint{
+				Latitude:    formatCoordinate(np.Latitude),
+				Longitude:   formatCoordinate(np.Longitude),
+				Name:        np.LocationName,
+				Description: parkDescription(np),
+				Type:        "National Park",
+			}
+		},
+	}
+}
+
+// GeoJSON, see https://datatracker.ietf.org/doc/html/rfc7946
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Id         int             `json:"id"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties db.NationalPark `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float32 `json:"coordinates"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+func newGeoJSONFeature(np db.NationalPark) geoJSONFeature {
+	return geoJSONFeature{
+		Type: "Feature",
+		Id:   np.Id,
+		// GeoJSON positions are longitude first.
+		Geometry:   geoJSONGeometry{Type: "Point", Coordinates: [2]float32{np.Longitude, np.Latitude}},
+		Properties: np,
+	}
+}
+
+// encodeGeoJSON renders a single park as a Feature and a list of parks as a FeatureCollection.
+func encodeGeoJSON(ctx context.Context, w io.Writer, data interface{}) error {
+	switch v := data.(type) {
+	case db.NationalPark:
+		return json.NewEncoder(w).Encode(newGeoJSONFeature(v))
+	case []db.NationalPark:
+		collection := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+		for _, np := range v {
+			collection.Features = append(collection.Features, newGeoJSONFeature(np))
+		}
+		return json.NewEncoder(w).Encode(collection)
+	default:
+		return ErrNotRepresentable
+	}
+}
+
+// geoJSONParkWriter streams a FeatureCollection by writing the surrounding object by hand and encoding one Feature at
+// a time.
+type geoJSONParkWriter struct {
+	w     io.Writer
+	count int
+}
+
+func newGeoJSONParkWriter(w io.Writer) ParkWriter {
+	return &geoJSONParkWriter{w: w}
+}
+
+func (g *geoJSONParkWriter) Begin() error {
+	_, err := io.WriteString(g.w, `{"type":"FeatureCollection","features":[`)
+	return err
+}
+
+func (g *geoJSONParkWriter) Write(np db.NationalPark) error {
+	b, err := json.Marshal(newGeoJSONFeature(np))
+	if err != nil {
+		return err
+	}
+	if g.count > 0 {
+		if _, err = io.WriteString(g.w, ","); err != nil {
+			return err
+		}
+	}
+	g.count++
+	_, err = g.w.Write(b)
+	return err
+}
+
+func (g *geoJSONParkWriter) End() error {
+	_, err := io.WriteString(g.w, "]}\n")
+	return err
+}
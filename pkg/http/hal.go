@@ -0,0 +1,129 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"nationalparks-rest/pkg/db"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// HAL, see https://datatracker.ietf.org/doc/html/draft-kelly-json-hal
+//
+// Every link is built from the named routes registered in server.go, so clients that follow links keep working when
+// URL patterns change.
+
+type halLink struct {
+	Href string `json:"href"`
+}
+
+type halLinks map[string]halLink
+
+// add records a link unless href is empty, which is what linkTo returns for a route that is not registered.
+func (l halLinks) add(rel string, href string) {
+	if href != "" {
+		l[rel] = halLink{Href: href}
+	}
+}
+
+type halPark struct {
+	db.NationalPark
+	Links halLinks `json:"_links"`
+}
+
+type halParks struct {
+	Links    halLinks `json:"_links"`
+	Start    int      `json:"start"`
+	Count    int      `json:"count"`
+	Embedded struct {
+		NationalParks []halPark `json:"nationalparks"`
+	} `json:"_embedded"`
+}
+
+// linkTo returns the path of the named route filled in with the given variable name/value pairs.
+func linkTo(name string, query url.Values, pairs ...string) string {
+	if router == nil {
+		return ""
+	}
+	route := router.Get(name)
+	if route == nil {
+		return ""
+	}
+	u, err := route.URL(pairs...)
+	if err != nil {
+		return ""
+	}
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+func newHALPark(np db.NationalPark) halPark {
+	links := halLinks{}
+	links.add("self", linkTo("nationalpark", nil, "id", strconv.Itoa(np.Id)))
+	links.add("same-state", linkTo("nationalparksByState", nil, "stateabbr", np.State))
+	links.add("same-city", linkTo("nationalparksByCity", nil, "city", np.City))
+	links.add("nearby", linkTo("nationalparksNear", url.Values{
+		"lat": {strconv.FormatFloat(float64(np.Latitude), 'f', -1, 32)},
+		"lon": {strconv.FormatFloat(float64(np.Longitude), 'f', -1, 32)},
+	}))
+	return halPark{NationalPark: np, Links: links}
+}
+
+// pageLink returns the URL of the current request moved to a different page.
+func pageLink(r *http.Request, start int, count int) string {
+	u := *r.URL
+	query := u.Query()
+	query.Set("start", strconv.Itoa(start))
+	query.Set("count", strconv.Itoa(count))
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
+
+func newHALParks(ctx context.Context, nps []db.NationalPark) halParks {
+	page := halParks{Links: halLinks{}, Count: len(nps)}
+	page.Embedded.NationalParks = []halPark{}
+	for _, np := range nps {
+		page.Embedded.NationalParks = append(page.Embedded.NationalParks, newHALPark(np))
+	}
+
+	r := requestFromContext(ctx)
+	if r == nil {
+		return page
+	}
+	// The handler already rejected invalid paging.
+	start, count, _ := listPaging(ctx, r)
+	page.Start = start
+
+	page.Links.add("self", r.URL.RequestURI())
+	page.Links.add("first", pageLink(r, 0, count))
+	if start > 0 {
+		prev := start - count
+		if prev < 0 {
+			prev = 0
+		}
+		page.Links.add("prev", pageLink(r, prev, count))
+	}
+	// A full page means there may be more rows after it.
+	if len(nps) == count {
+		page.Links.add("next", pageLink(r, start+count, count))
+	}
+	return page
+}
+
+// encodeHAL adds _links to parks and park lists.  Anything else has no resources to link and is sent as plain JSON.
+func encodeHAL(ctx context.Context, w io.Writer, data interface{}) error {
+	switch v := data.(type) {
+	case db.NationalPark:
+		data = newHALPark(v)
+	case []db.NationalPark:
+		data = newHALParks(ctx, v)
+	}
+	enc := json.NewEncoder(w)
+	// Keep the & separating query parameters in links readable.
+	enc.SetEscapeHTML(false)
+	return enc.Encode(data)
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"nationalparks-rest/pkg"
+	"nationalparks-rest/pkg/db"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// How long the readiness checks may take together.  The probe in the Kubernetes deployment waits a little longer, so
+// that a slow database is reported as failing rather than as a probe timeout.
+const readinessTimeout = 2 * time.Second
+
+// Health is the body of the liveness and readiness endpoints.
+type Health struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// HealthCheck is the outcome of one readiness check.
+type HealthCheck struct {
+	Status     string   `json:"status"`
+	Error      string   `json:"error,omitempty"`
+	Pending    []string `json:"pending,omitempty"`
+	DurationMs float64  `json:"duration_ms"`
+}
+
+// readinessCheck is a named check for /readyz.  check returns an error when the service is not ready, along with the
+// outstanding work, if any, that keeps it from being ready.  A check that is not required only degrades the service
+// when it fails: it is reported, but the service stays ready.
+type readinessCheck struct {
+	name     string
+	required bool
+	check    func(ctx context.Context) ([]string, error)
+}
+
+// Set to 1 once the service is shutting down, see SetShuttingDown.
+var shuttingDown int32
+
+// SetShuttingDown makes /readyz fail from now on, so that no new traffic is routed to the service while it drains the
+// requests in flight.
+func SetShuttingDown() {
+	atomic.StoreInt32(&shuttingDown, 1)
+}
+
+// The checks /readyz runs, in order.  The service keeps no cache, so there is nothing to warm up; the database is
+// the only dependency requests need.  Pending migrations only break changes to parks and the features built on the
+// change log, the read-only endpoints still work, see Migrate in main.
+var readinessChecks = []readinessCheck{
+	{name: "database", required: true, check: func(ctx context.Context) ([]string, error) {
+		return nil, dtb.PingContext(ctx)
+	}},
+	{name: "migrations", required: false, check: func(ctx context.Context) ([]string, error) {
+		pending, err := db.PendingMigrations(ctx, dtb)
+		if err == nil && len(pending) > 0 {
+			err = errors.New("migrations have not been applied")
+		}
+		return pending, err
+	}},
+}
+
+// RouteLiveness reports that the process is up and serving requests.  It checks nothing else, so that Kubernetes
+// does not restart pods because the database is down.
+func RouteLiveness(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteLiveness() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteLiveness")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	respondWithSuccess(ctx, Health{Status: "ok"}, w)
+}
+
+// RouteReadiness reports whether the service can answer requests: the database answers a ping.  It responds with 503
+// Service Unavailable and the failing checks otherwise, and from the start of a shutdown, so that traffic is only
+// routed to pods that can serve it.  Failing checks that are not required, such as pending migrations, are reported
+// with a degraded status and 200 OK.
+func RouteReadiness(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteReadiness() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteReadiness")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	if atomic.LoadInt32(&shuttingDown) == 1 {
+		span.SetAttributes(attribute.Bool("shutting-down", true))
+		respondWithStatus(ctx, http.StatusServiceUnavailable, Health{Status: "shutting down"}, w)
+		return
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	health := Health{Status: "ok", Checks: make(map[string]HealthCheck)}
+	for _, c := range readinessChecks {
+		start := time.Now()
+		pending, err := c.check(checkCtx)
+		result := HealthCheck{
+			Status:     "ok",
+			Pending:    pending,
+			DurationMs: float64(time.Since(start)) / float64(time.Millisecond),
+		}
+		if err != nil {
+			result.Error = err.Error()
+			if c.required {
+				result.Status = "failing"
+				health.Status = "failing"
+			} else {
+				result.Status = "degraded"
+				if health.Status == "ok" {
+					health.Status = "degraded"
+				}
+			}
+			log.WithContext(ctx).Warnf("Readiness check %s failed: %v", c.name, err)
+		}
+		span.SetAttributes(attribute.String("check."+c.name, result.Status))
+		health.Checks[c.name] = result
+	}
+
+	status := http.StatusOK
+	if health.Status == "failing" {
+		status = http.StatusServiceUnavailable
+	}
+	respondWithStatus(ctx, status, health, w)
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/unit"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"nationalparks-rest/pkg"
+	"net/http"
+	"time"
+)
+
+var (
+	meter = metric.Must(global.Meter(pkg.METER_NAME))
+
+	requestCounter = meter.NewInt64Counter("http.server.requests",
+		metric.WithDescription("Requests served, by route, method and status"))
+	errorCounter = meter.NewInt64Counter("http.server.errors",
+		metric.WithDescription("Requests that failed with a 5xx status, by route, method and status"))
+	requestDuration = meter.NewFloat64Histogram("http.server.duration",
+		metric.WithDescription("Time taken to serve a request"), metric.WithUnit(unit.Milliseconds))
+)
+
+// Metrics is a middleware recording the request count, latency and error count of every route.  Routes are labelled
+// with their path template rather than the request path, so the number of series stays bounded.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		start := time.Now()
+		wrapped, m := wrapResponseWriter(w, nil)
+		next.ServeHTTP(wrapped, r)
+		duration := time.Since(start)
+
+		labels := []attribute.KeyValue{
+			semconv.HTTPRouteKey.String(route),
+			semconv.HTTPMethodKey.String(r.Method),
+			semconv.HTTPStatusCodeKey.Int(m.Code),
+		}
+		ctx := r.Context()
+		requestCounter.Add(ctx, 1, labels...)
+		requestDuration.Record(ctx, float64(duration)/float64(time.Millisecond), labels...)
+		if m.Code >= http.StatusInternalServerError {
+			errorCounter.Add(ctx, 1, labels...)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"nationalparks-rest/pkg"
+	"nationalparks-rest/pkg/db"
+	"net/http"
+	"strconv"
+)
+
+// Request bodies are parks in the same JSON form the API returns.  Every mutation is recorded in the change log in
+// the same transaction, see db.NationalParkChange.
+
+func RouteCreateNationalPark(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteCreateNationalPark() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteCreateNationalPark")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	np, err := decodePark(r)
+	if err != nil {
+		respondWithErrorStatus(ctx, http.StatusBadRequest, err, w)
+		return
+	}
+
+	np, err = db.DBCreateNationalPark(ctx, dtb, np)
+	if err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+	span.SetAttributes(attribute.Int("id", np.Id))
+	notifyChange()
+
+	if location := linkTo("nationalpark", nil, "id", strconv.Itoa(np.Id)); location != "" {
+		w.Header().Set("Location", location)
+	}
+	respondWithStatus(ctx, http.StatusCreated, np, w)
+}
+
+func RouteUpdateNationalPark(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteUpdateNationalPark() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteUpdateNationalPark")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id, _ := strconv.Atoi(vars["id"])
+	span.SetAttributes(attribute.Int("id", id))
+
+	np, err := decodePark(r)
+	if err != nil {
+		respondWithErrorStatus(ctx, http.StatusBadRequest, err, w)
+		return
+	}
+	if np.Id != 0 && np.Id != id {
+		respondWithErrorStatus(ctx, http.StatusBadRequest, fmt.Errorf("id %d in the body does not match id %d in the path", np.Id, id), w)
+		return
+	}
+	np.Id = id
+
+	np, err = db.DBUpdateNationalPark(ctx, dtb, np)
+	if err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+	notifyChange()
+
+	respondWithSuccess(ctx, np, w)
+}
+
+func RouteDeleteNationalPark(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteDeleteNationalPark() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteDeleteNationalPark")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id, _ := strconv.Atoi(vars["id"])
+	span.SetAttributes(attribute.Int("id", id))
+
+	if _, err := db.DBDeleteNationalPark(ctx, dtb, id); err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+	notifyChange()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodePark reads the park in the request body.  Unknown fields are rejected so that a misspelled field is reported
+// instead of silently clearing the column it was meant for.
+func decodePark(r *http.Request) (db.NationalPark, error) {
+	var np db.NationalPark
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&np); err != nil {
+		return np, fmt.Errorf("invalid park: %v", err)
+	}
+
+	if np.LocationName == "" {
+		return np, fmt.Errorf("invalid park: location_name is required")
+	}
+	if len(np.State) != 2 {
+		return np, fmt.Errorf("invalid park: state must be a two letter abbreviation")
+	}
+	if np.Latitude < -90 || np.Latitude > 90 {
+		return np, fmt.Errorf("invalid park: bad latitude: %v", np.Latitude)
+	}
+	if np.Longitude < -180 || np.Longitude > 180 {
+		return np, fmt.Errorf("invalid park: bad longitude: %v", np.Longitude)
+	}
+	return np, nil
+}
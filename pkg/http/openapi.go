@@ -0,0 +1,67 @@
+package http
+
+import (
+	"embed"
+	"fmt"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+)
+
+// APIPrefix is the path every versioned route is registered under, and the server URL of the OpenAPI document.
+const APIPrefix = "/api/v1"
+
+// The OpenAPI document, and the Swagger UI page rendering it with the swagger-ui-dist assets vendored by
+// vendor-swagger-ui.sh, so that the page loads nothing from outside the service.
+//
+//go:embed openapi
+var openAPIFiles embed.FS
+
+var openAPIDocument = mustReadFile(openAPIFiles, "openapi/openapi.json")
+var swaggerUI = mustReadFile(openAPIFiles, "openapi/swagger.html")
+
+func mustReadFile(fsys fs.FS, name string) []byte {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func RouteOpenAPI(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteOpenAPI() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPIDocument)
+}
+
+func RouteDocs(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteDocs() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(swaggerUI)
+}
+
+// RouteSwaggerUIAsset serves the vendored Swagger UI stylesheet and script the docs page refers to.
+func RouteSwaggerUIAsset(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteSwaggerUIAsset() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	name := mux.Vars(r)["file"]
+	data, err := fs.ReadFile(openAPIFiles, path.Join("openapi/swagger-ui", name))
+	if err != nil {
+		respondWithErrorStatus(r.Context(), http.StatusNotFound, fmt.Errorf("no such file: %s", name), w)
+		return
+	}
+
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
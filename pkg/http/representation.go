@@ -0,0 +1,260 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/vmihailenco/msgpack/v5"
+	"io"
+	"mime"
+	"nationalparks-rest/pkg/db"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Representation is one of the formats a response body can be rendered in.  Representations are picked per request
+// by the Negotiate middleware, so adding a format only requires registering it with RegisterRepresentation.
+type Representation struct {
+	// MediaType is matched against the Accept header and sent back as the Content-Type.
+	MediaType string
+
+	// Format is the value of the `format` query parameter that selects this representation, overriding Accept.
+	Format string
+
+	// Extension, when set, marks the representation as a download: park responses are sent with a
+	// Content-Disposition header naming a file with this extension.
+	Extension string
+
+	// Encode renders data to w.  It returns ErrNotRepresentable for data the format has no way of expressing.  ctx is
+	// the request context, giving access to the request through requestFromContext.
+	Encode func(ctx context.Context, w io.Writer, data interface{}) error
+
+	// NewParkWriter is optional.  When set, park lists are streamed straight from the database through the writer
+	// and, unless the client asks for a specific count, include every matching row.
+	NewParkWriter func(w io.Writer) ParkWriter
+}
+
+// ParkWriter renders a stream of NationalPark values to a response body one at a time so that an export never needs
+// the whole result set in memory.
+type ParkWriter interface {
+	Begin() error
+	Write(np db.NationalPark) error
+	End() error
+}
+
+// ErrNotRepresentable is returned by an Encode function that cannot express the data it was handed.
+var ErrNotRepresentable = errors.New("data cannot be represented in the requested media type")
+
+var representations []*Representation
+
+// RegisterRepresentation adds rep to the formats offered by every endpoint.  The first registered representation is
+// the default for requests without an Accept header.
+func RegisterRepresentation(rep Representation) {
+	representations = append(representations, &rep)
+}
+
+type representationKey struct{}
+type requestKey struct{}
+
+// Negotiate is a middleware that picks the Representation for a request from its `format` query parameter or Accept
+// header and stores it in the request context for the respondWith* helpers.  Requests asking only for formats that are
+// not registered are answered with 406 Not Acceptable.
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		rep := negotiate(r)
+		if rep == nil {
+			respondNotAcceptable(w)
+			return
+		}
+		ctx := context.WithValue(r.Context(), representationKey{}, rep)
+		ctx = context.WithValue(ctx, requestKey{}, r)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// representationFromContext returns the negotiated Representation, falling back to the default for handlers that are
+// not wrapped by Negotiate.
+func representationFromContext(ctx context.Context) *Representation {
+	if rep, ok := ctx.Value(representationKey{}).(*Representation); ok {
+		return rep
+	}
+	return representations[0]
+}
+
+// requestFromContext returns the request being answered, or nil for handlers that are not wrapped by Negotiate.
+func requestFromContext(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(requestKey{}).(*http.Request)
+	return r
+}
+
+func negotiate(r *http.Request) *Representation {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		for _, rep := range representations {
+			if rep.Format == format {
+				return rep
+			}
+		}
+		return nil
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return representations[0]
+	}
+
+	for _, mediaRange := range parseAccept(accept) {
+		for _, rep := range representations {
+			if mediaRange.matches(rep.MediaType) {
+				return rep
+			}
+		}
+	}
+	return nil
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+func (m mediaRange) matches(mediaType string) bool {
+	if m.mediaType == "*/*" || m.mediaType == mediaType {
+		return true
+	}
+	if strings.HasSuffix(m.mediaType, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(m.mediaType, "*"))
+	}
+	return false
+}
+
+// parseAccept splits an Accept header into its media ranges ordered by preference.  Ranges with q=0 are dropped and,
+// as RFC 7231 suggests, more specific ranges win over wildcards of the same quality.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+		}
+	}
+
+	specificity := func(m mediaRange) int {
+		return 2 - strings.Count(m.mediaType, "*")
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].quality != ranges[j].quality {
+			return ranges[i].quality > ranges[j].quality
+		}
+		return specificity(ranges[i]) > specificity(ranges[j])
+	})
+	return ranges
+}
+
+func respondNotAcceptable(w http.ResponseWriter) {
+	var available []string
+	for _, rep := range representations {
+		available = append(available, fmt.Sprintf("%s (format=%s)", rep.MediaType, rep.Format))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotAcceptable)
+	json.NewEncoder(w).Encode("not acceptable, available representations are: " + strings.Join(available, ", "))
+}
+
+// encodeWithParkWriter adapts a streaming ParkWriter into an Encode function for single parks and park slices.
+func encodeWithParkWriter(newWriter func(w io.Writer) ParkWriter) func(ctx context.Context, w io.Writer, data interface{}) error {
+	return func(ctx context.Context, w io.Writer, data interface{}) error {
+		var parks []db.NationalPark
+		switch v := data.(type) {
+		case db.NationalPark:
+			parks = []db.NationalPark{v}
+		case []db.NationalPark:
+			parks = v
+		default:
+			return ErrNotRepresentable
+		}
+
+		pw := newWriter(w)
+		if err := pw.Begin(); err != nil {
+			return err
+		}
+		for _, np := range parks {
+			if err := pw.Write(np); err != nil {
+				return err
+			}
+		}
+		return pw.End()
+	}
+}
+
+func encodeJSON(ctx context.Context, w io.Writer, data interface{}) error {
+	return json.NewEncoder(w).Encode(data)
+}
+
+func encodePrettyJSON(ctx context.Context, w io.Writer, data interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+type xmlNationalParks struct {
+	XMLName xml.Name          `xml:"nationalparks"`
+	Parks   []db.NationalPark `xml:"nationalpark"`
+}
+
+func encodeXML(ctx context.Context, w io.Writer, data interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	switch v := data.(type) {
+	case db.NationalPark:
+		return enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: "nationalpark"}})
+	case []db.NationalPark:
+		return enc.Encode(xmlNationalParks{Parks: v})
+	case string:
+		return enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: "message"}})
+	default:
+		return enc.Encode(v)
+	}
+}
+
+func encodeMessagePack(ctx context.Context, w io.Writer, data interface{}) error {
+	enc := msgpack.NewEncoder(w)
+	// Reuse the JSON field names so both formats describe a park the same way.
+	enc.SetCustomStructTag("json")
+	return enc.Encode(data)
+}
+
+func init() {
+	RegisterRepresentation(Representation{MediaType: "application/json", Format: "json", Encode: encodeJSON})
+	// Pretty printed JSON shares its media type with plain JSON, so it can only be selected with format=pretty.
+	RegisterRepresentation(Representation{MediaType: "application/json", Format: "pretty", Encode: encodePrettyJSON})
+	RegisterRepresentation(Representation{MediaType: "application/hal+json", Format: "hal", Encode: encodeHAL})
+	RegisterRepresentation(Representation{MediaType: "application/xml", Format: "xml", Encode: encodeXML})
+	RegisterRepresentation(Representation{MediaType: "application/msgpack", Format: "msgpack", Encode: encodeMessagePack})
+	RegisterRepresentation(Representation{MediaType: "application/geo+json", Format: "geojson", Extension: "geojson",
+		Encode: encodeGeoJSON, NewParkWriter: newGeoJSONParkWriter})
+	RegisterRepresentation(Representation{MediaType: "text/csv", Format: "csv", Extension: "csv",
+		Encode: encodeWithParkWriter(newCSVParkWriter), NewParkWriter: newCSVParkWriter})
+	RegisterRepresentation(Representation{MediaType: "application/x-ndjson", Format: "ndjson", Extension: "ndjson",
+		Encode: encodeWithParkWriter(newNDJSONParkWriter), NewParkWriter: newNDJSONParkWriter})
+	RegisterRepresentation(Representation{MediaType: "application/vnd.google-earth.kml+xml", Format: "kml", Extension: "kml",
+		Encode: encodeWithParkWriter(newKMLParkWriter), NewParkWriter: newKMLParkWriter})
+	RegisterRepresentation(Representation{MediaType: "application/gpx+xml", Format: "gpx", Extension: "gpx",
+		Encode: encodeWithParkWriter(newGPXParkWriter), NewParkWriter: newGPXParkWriter})
+}
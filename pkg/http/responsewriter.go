@@ -0,0 +1,66 @@
+package http
+
+import (
+	"github.com/felixge/httpsnoop"
+	"io"
+	"net/http"
+)
+
+// responseStats is what wrapResponseWriter saw of a response.
+type responseStats struct {
+	// Code is the status sent, 200 OK until one is.
+	Code int
+	// Written is the number of body bytes written.
+	Written int64
+}
+
+// wrapResponseWriter returns a writer passing everything on to w, which calls beforeHeader, unless it is nil, just
+// before the response header is sent, and records the status and size of the response.  Middlewares use it instead of
+// a struct embedding http.ResponseWriter, which would hide the optional interfaces of w: the change feed streams by
+// type asserting http.Flusher, and httpsnoop keeps every interface w implements.
+func wrapResponseWriter(w http.ResponseWriter, beforeHeader func()) (http.ResponseWriter, *responseStats) {
+	stats := &responseStats{Code: http.StatusOK}
+	var headerSent bool
+	sendHeader := func(code int) {
+		if headerSent {
+			return
+		}
+		headerSent = true
+		stats.Code = code
+		if beforeHeader != nil {
+			beforeHeader()
+		}
+	}
+
+	wrapped := httpsnoop.Wrap(w, httpsnoop.Hooks{
+		WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+			return func(code int) {
+				sendHeader(code)
+				next(code)
+			}
+		},
+		Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+			return func(b []byte) (int, error) {
+				sendHeader(http.StatusOK)
+				n, err := next(b)
+				stats.Written += int64(n)
+				return n, err
+			}
+		},
+		ReadFrom: func(next httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+			return func(src io.Reader) (int64, error) {
+				sendHeader(http.StatusOK)
+				n, err := next(src)
+				stats.Written += n
+				return n, err
+			}
+		},
+		Flush: func(next httpsnoop.FlushFunc) httpsnoop.FlushFunc {
+			return func() {
+				sendHeader(http.StatusOK)
+				next()
+			}
+		},
+	})
+	return wrapped, stats
+}
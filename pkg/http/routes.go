@@ -1,9 +1,11 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
@@ -13,16 +15,24 @@ import (
 	"nationalparks-rest/pkg/db"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 var dtb *sql.DB
+var router *mux.Router
 
 func SetDB(d *sql.DB) {
 	dtb = d
 }
 
+// SetRouter gives the handlers access to the named routes so that links in responses are built from the route
+// definitions instead of hard-coded URL patterns.
+func SetRouter(r *mux.Router) {
+	router = r
+}
+
 func RouteHealthCheck(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("RouteHealthCheck() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+	log.WithContext(r.Context()).Debugf("RouteHealthCheck() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
 
 	// Create a child span.
 	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
@@ -34,7 +44,7 @@ func RouteHealthCheck(w http.ResponseWriter, r *http.Request) {
 }
 
 func RouteGetNationalParkById(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("RouteGetNationalParkById() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+	log.WithContext(r.Context()).Debugf("RouteGetNationalParkById() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
 
 	// Create a child span.
 	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
@@ -50,12 +60,12 @@ func RouteGetNationalParkById(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		respondWithError(ctx, err, w)
 	} else {
-		respondWithSuccess(ctx, np, w)
+		respondWithPark(ctx, np, exportFilename("nationalpark", vars["id"]), w)
 	}
 }
 
 func RouteGetNationalParkByName(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("RouteGetNationalParkByName() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+	log.WithContext(r.Context()).Debugf("RouteGetNationalParkByName() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
 
 	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
 	ctx, span := tracer.Start(r.Context(), "RouteGetNationalParkByName")
@@ -63,19 +73,19 @@ func RouteGetNationalParkByName(w http.ResponseWriter, r *http.Request) {
 	defer span.End()
 
 	vars := mux.Vars(r)
-	var name = vars["name"]
+	var name = vars["parkname"]
 	span.SetAttributes(attribute.String("park-name", name))
 
 	np, err := db.DBGetNationalParkByName(ctx, dtb, name)
 	if err != nil {
 		respondWithError(ctx, err, w)
 	} else {
-		respondWithSuccess(ctx, np, w)
+		respondWithPark(ctx, np, exportFilename("nationalpark", name), w)
 	}
 }
 
 func RouteGetNationalParks(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("RouteGetNationalParks() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+	log.WithContext(r.Context()).Debugf("RouteGetNationalParks() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
 
 	// Create a child span.
 	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
@@ -83,36 +93,25 @@ func RouteGetNationalParks(w http.ResponseWriter, r *http.Request) {
 	pkg.AddTraceParentToResponse(span, w)
 	defer span.End()
 
-	var err error
-	var nps []db.NationalPark
-	var start, count int
-
 	var city = r.FormValue("city")
 	var state = r.FormValue("state")
 	var zipcode = r.FormValue("zipcode")
-
-	start, err = strconv.Atoi(r.FormValue("start"))
-	if err != nil {
-		start = 0
-	}
-	count, err = strconv.Atoi(r.FormValue("count"))
+	start, count, err := listPaging(ctx, r)
 	if err != nil {
-		count = 5
+		respondWithError(ctx, err, w)
+		return
 	}
 
 	span.SetAttributes(attribute.Int("start", start))
 	span.SetAttributes(attribute.Int("count", count))
 
-	nps, err = db.DBGetNationalParks(ctx, dtb, city, state, zipcode, start, count)
-	if err != nil {
-		respondWithError(ctx, err, w)
-	} else {
-		respondWithSuccess(ctx, nps, w)
-	}
+	respondWithParks(ctx, exportFilename("nationalparks", city, state, zipcode), w, func(visit db.NationalParkVisitor) error {
+		return db.DBVisitNationalParks(ctx, dtb, city, state, zipcode, start, count, visit)
+	})
 }
 
 func RouteGetNationalParksByCity(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("RouteGetNationalParksByCity() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+	log.WithContext(r.Context()).Debugf("RouteGetNationalParksByCity() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
 
 	// Create a child span.
 	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
@@ -120,34 +119,24 @@ func RouteGetNationalParksByCity(w http.ResponseWriter, r *http.Request) {
 	pkg.AddTraceParentToResponse(span, w)
 	defer span.End()
 
-	var err error
-	var nps []db.NationalPark
-	var start, count int
-
 	vars := mux.Vars(r)
 	var city = vars["city"]
-	start, err = strconv.Atoi(vars["start"])
+	start, count, err := listPaging(ctx, r)
 	if err != nil {
-		start = 0
-	}
-	count, err = strconv.Atoi(vars["count"])
-	if err != nil {
-		count = 5
+		respondWithError(ctx, err, w)
+		return
 	}
 
 	span.SetAttributes(attribute.Int("start", start))
 	span.SetAttributes(attribute.Int("count", count))
 
-	nps, err = db.DBGetNationalParksByCity(ctx, dtb, city, start, count)
-	if err != nil {
-		respondWithError(ctx, err, w)
-	} else {
-		respondWithSuccess(ctx, nps, w)
-	}
+	respondWithParks(ctx, exportFilename("nationalparks", city), w, func(visit db.NationalParkVisitor) error {
+		return db.DBVisitNationalParksByCity(ctx, dtb, city, start, count, visit)
+	})
 }
 
 func RouteGetNationalParksByState(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("RouteGetNationalParksByState() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+	log.WithContext(r.Context()).Debugf("RouteGetNationalParksByState() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
 
 	// Create a child span.
 	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
@@ -155,34 +144,24 @@ func RouteGetNationalParksByState(w http.ResponseWriter, r *http.Request) {
 	pkg.AddTraceParentToResponse(span, w)
 	defer span.End()
 
-	var err error
-	var nps []db.NationalPark
-	var start, count int
-
 	vars := mux.Vars(r)
 	var state = vars["stateabbr"]
-	start, err = strconv.Atoi(vars["start"])
+	start, count, err := listPaging(ctx, r)
 	if err != nil {
-		start = 0
-	}
-	count, err = strconv.Atoi(vars["count"])
-	if err != nil {
-		count = 5
+		respondWithError(ctx, err, w)
+		return
 	}
 
 	span.SetAttributes(attribute.Int("start", start))
 	span.SetAttributes(attribute.Int("count", count))
 
-	nps, err = db.DBGetNationalParksByState(ctx, dtb, state, start, count)
-	if err != nil {
-		respondWithError(ctx,err, w)
-	} else {
-		respondWithSuccess(ctx, nps, w)
-	}
+	respondWithParks(ctx, exportFilename("nationalparks", state), w, func(visit db.NationalParkVisitor) error {
+		return db.DBVisitNationalParksByState(ctx, dtb, state, start, count, visit)
+	})
 }
 
 func RouteGetNationalParksByZipCode(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("RouteGetNationalParksByZipCode() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+	log.WithContext(r.Context()).Debugf("RouteGetNationalParksByZipCode() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
 
 	// Create a child span.
 	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
@@ -190,54 +169,145 @@ func RouteGetNationalParksByZipCode(w http.ResponseWriter, r *http.Request) {
 	pkg.AddTraceParentToResponse(span, w)
 	defer span.End()
 
-	var err error
-	var nps []db.NationalPark
-	var zipCode, start, count int
-
 	vars := mux.Vars(r)
-	zipCode, err = strconv.Atoi(vars["zipcode"])
+	zipCode, err := strconv.Atoi(vars["zipcode"])
 	if err != nil {
-		respondWithError(ctx, fmt.Errorf("bad ZipCode: %s", vars["zipCode"]), w)
-	} else {
-		start, err = strconv.Atoi(vars["start"])
-		if err != nil {
-			start = 0
-		}
-		count, err = strconv.Atoi(vars["count"])
-		if err != nil {
-			count = 5
-		}
+		respondWithErrorStatus(ctx, http.StatusBadRequest, fmt.Errorf("bad ZipCode: %s", vars["zipcode"]), w)
+		return
+	}
+	start, count, err := listPaging(ctx, r)
+	if err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
 
-		span.SetAttributes(attribute.Int("start", start))
-		span.SetAttributes(attribute.Int("count", count))
+	span.SetAttributes(attribute.Int("start", start))
+	span.SetAttributes(attribute.Int("count", count))
 
-		nps, err = db.DBGetNationalParksByZipCode(ctx, dtb, zipCode, start, count)
-		if err != nil {
-			respondWithError(ctx, err, w)
-		} else {
-			respondWithSuccess(ctx, nps, w)
-		}
+	respondWithParks(ctx, exportFilename("nationalparks", vars["zipcode"]), w, func(visit db.NationalParkVisitor) error {
+		return db.DBVisitNationalParksByZipCode(ctx, dtb, zipCode, start, count, visit)
+	})
+}
+
+func RouteGetNationalParksNear(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteGetNationalParksNear() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteGetNationalParksNear")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	latitude, err := strconv.ParseFloat(r.FormValue("lat"), 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		respondWithErrorStatus(ctx, http.StatusBadRequest, fmt.Errorf("bad latitude: %s", r.FormValue("lat")), w)
+		return
+	}
+	longitude, err := strconv.ParseFloat(r.FormValue("lon"), 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		respondWithErrorStatus(ctx, http.StatusBadRequest, fmt.Errorf("bad longitude: %s", r.FormValue("lon")), w)
+		return
+	}
+	radius, err := strconv.ParseFloat(r.FormValue("radius"), 64)
+	if err != nil || radius <= 0 {
+		radius = db.DefaultNearRadiusMiles
 	}
+	start, count, err := listPaging(ctx, r)
+	if err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+
+	span.SetAttributes(attribute.Float64("latitude", latitude))
+	span.SetAttributes(attribute.Float64("longitude", longitude))
+	span.SetAttributes(attribute.Float64("radius", radius))
+	span.SetAttributes(attribute.Int("start", start))
+	span.SetAttributes(attribute.Int("count", count))
+
+	respondWithParks(ctx, "nationalparks-near", w, func(visit db.NationalParkVisitor) error {
+		return db.DBVisitNationalParksNear(ctx, dtb, latitude, longitude, radius, start, count, visit)
+	})
+}
+
+// badRequestError marks an error as caused by the request, which respondWithError answers with 400 Bad Request.
+type badRequestError struct {
+	error
+}
+
+func (e badRequestError) Unwrap() error {
+	return e.error
+}
+
+func badRequest(err error) error {
+	return badRequestError{err}
 }
 
 // Helper functions for respond with 200 or 500 code
 func respondWithError(ctx context.Context, err error, w http.ResponseWriter) {
+	var bad badRequestError
+	if errors.As(err, &bad) {
+		respondWithErrorStatus(ctx, http.StatusBadRequest, err, w)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithErrorStatus(ctx, http.StatusNotFound, err, w)
+		return
+	}
+	if errors.Is(err, db.ErrUnavailable) {
+		// Round up, a client retrying a little early would only be turned away again.
+		retryAfter := int((db.BreakerRetryAfter() + time.Second - 1) / time.Second)
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		respondWithErrorStatus(ctx, http.StatusServiceUnavailable, err, w)
+		return
+	}
+	respondWithErrorStatus(ctx, http.StatusInternalServerError, err, w)
+}
+
+func respondWithErrorStatus(ctx context.Context, status int, err error, w http.ResponseWriter) {
 	// Create a child span.
 	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
 	_, span := tracer.Start(ctx, "respondWithError")
 	defer span.End()
 
-	w.WriteHeader(http.StatusInternalServerError)
+	w.Header().Del("Content-Disposition")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(err.Error())
 }
 
 func respondWithSuccess(ctx context.Context, data interface{}, w http.ResponseWriter) {
+	respondWithStatus(ctx, http.StatusOK, data, w)
+}
+
+func respondWithStatus(ctx context.Context, status int, data interface{}, w http.ResponseWriter) {
 	// Create a child span.
 	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
 	_, span := tracer.Start(ctx, "respondWithSuccess")
 	defer span.End()
 
-	w.Header().Add("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(data)
+	rep := representationFromContext(ctx)
+	span.SetAttributes(attribute.String("content-type", rep.MediaType))
+
+	// Encode into a buffer first so that a failure can still change the status code.
+	var body bytes.Buffer
+	stop := pkg.TimerFromContext(ctx).Start(pkg.PhaseSerialize)
+	err := rep.Encode(ctx, &body, data)
+	stop()
+	if err != nil {
+		span.RecordError(err)
+		if err == ErrNotRepresentable {
+			w.Header().Del("Content-Disposition")
+			respondNotAcceptable(w)
+		} else {
+			respondWithError(ctx, err, w)
+		}
+		return
+	}
+
+	w.Header().Add("Content-Type", rep.MediaType)
+	w.WriteHeader(status)
+	w.Write(body.Bytes())
 }
@@ -0,0 +1,19 @@
+package http
+
+import (
+	"nationalparks-rest/pkg"
+	"net/http"
+)
+
+// ServerTiming is a middleware that puts a pkg.Timer into the request context and reports the phases it timed, and
+// the total, in a Server-Timing header just before the response headers are sent.  Responses that are streamed report
+// the phases up to their first byte; respondWithExport repeats the final values in a trailer.
+func ServerTiming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timer := pkg.NewTimer()
+		wrapped, _ := wrapResponseWriter(w, func() {
+			w.Header().Add("Server-Timing", timer.ServerTiming())
+		})
+		next.ServeHTTP(wrapped, r.WithContext(pkg.ContextWithTimer(r.Context(), timer)))
+	})
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"sync"
+	"time"
+)
+
+// How often CertReloader looks at the files for changes, at most.
+const certCheckInterval = time.Second
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion returns the TLS version for a version number such as 1.2.
+func ParseTLSVersion(version string) (uint16, error) {
+	if v, ok := tlsVersions[version]; ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("%q is not one of 1.0, 1.1, 1.2 or 1.3", version)
+}
+
+// CertReloader serves a certificate, and the CAs client certificates must be signed by, from PEM files that are
+// loaded again when they change, so that renewed certificates, such as those cert-manager writes into Kubernetes
+// secrets, are used without a restart.  Until the changed files load, for instance while only the certificate but
+// not its key has been replaced, it keeps serving the previous ones.
+type CertReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu        sync.Mutex
+	checked   time.Time
+	loaded    [3]time.Time
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// NewCertReloader loads the certificate from certFile and keyFile, and the client CAs from clientCAFile unless it is
+// empty.
+func NewCertReloader(certFile string, keyFile string, clientCAFile string) (*CertReloader, error) {
+	c := &CertReloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	modTimes, err := c.modTimes()
+	if err == nil {
+		err = c.load(modTimes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.checked = time.Now()
+	return c, nil
+}
+
+// TLSConfig returns a server configuration offering nextProtos, such as h2 and http/1.1, with the current certificate
+// to clients of at least minVersion.  With client CAs, clients must present a certificate signed by one of them, or
+// only when they present one unless requireClientCert is set.
+func (c *CertReloader) TLSConfig(minVersion uint16, requireClientCert bool, nextProtos ...string) *tls.Config {
+	clientAuth := tls.NoClientCert
+	if c.clientCAFile != "" {
+		clientAuth = tls.VerifyClientCertIfGiven
+		if requireClientCert {
+			clientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return &tls.Config{
+		MinVersion: minVersion,
+		NextProtos: nextProtos,
+		// Only consulted without GetConfigForClient, but http.Server wants to see a certificate.
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, _ := c.current()
+			return cert, nil
+		},
+		// Every handshake gets the current files.  A config, rather than just GetCertificate, so the client CAs are
+		// current as well.
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, clientCAs := c.current()
+			return &tls.Config{
+				MinVersion:   minVersion,
+				NextProtos:   nextProtos,
+				Certificates: []tls.Certificate{*cert},
+				ClientAuth:   clientAuth,
+				ClientCAs:    clientCAs,
+			}, nil
+		},
+	}
+}
+
+// current returns the certificate and client CAs, after loading them again if the files changed.
+func (c *CertReloader) current() (*tls.Certificate, *x509.CertPool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checked) >= certCheckInterval {
+		c.checked = time.Now()
+		modTimes, err := c.modTimes()
+		if err == nil && modTimes != c.loaded {
+			err = c.load(modTimes)
+			if err == nil {
+				log.Printf("Reloaded the TLS certificate from %s", c.certFile)
+			}
+		}
+		if err != nil {
+			log.Warnf("Keeping the current TLS certificate: %v", err)
+		}
+	}
+	return c.cert, c.clientCAs
+}
+
+func (c *CertReloader) modTimes() ([3]time.Time, error) {
+	var modTimes [3]time.Time
+	for i, name := range []string{c.certFile, c.keyFile, c.clientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return modTimes, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// load loads the files, and remembers modTimes as their modification times once they all loaded.
+func (c *CertReloader) load(modTimes [3]time.Time) error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading the TLS certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if c.clientCAFile != "" {
+		pem, err := os.ReadFile(c.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("loading the TLS client CAs: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("loading the TLS client CAs: no certificates in %s", c.clientCAFile)
+		}
+	}
+
+	c.cert, c.clientCAs, c.loaded = &cert, clientCAs, modTimes
+	return nil
+}
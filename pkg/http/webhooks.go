@@ -0,0 +1,199 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"nationalparks-rest/pkg"
+	"nationalparks-rest/pkg/db"
+	"nationalparks-rest/pkg/webhook"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Shortest secret accepted for a subscription; shorter ones make the HMAC signature easy to brute force.
+const minWebhookSecretLength = 16
+
+// Size of the EVENT_TYPES column the event types of a subscription are stored in, joined with commas.
+const maxEventTypesLength = 64
+
+var webhookDispatcher *webhook.Dispatcher
+
+// SetWebhookDispatcher gives the mutation handlers the dispatcher to wake up after a change.
+func SetWebhookDispatcher(d *webhook.Dispatcher) {
+	webhookDispatcher = d
+}
+
+func RouteCreateWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteCreateWebhookSubscription() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteCreateWebhookSubscription")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	subscription, err := decodeWebhookSubscription(r)
+	if err != nil {
+		respondWithErrorStatus(ctx, http.StatusBadRequest, err, w)
+		return
+	}
+
+	subscription, err = db.DBCreateWebhookSubscription(ctx, dtb, subscription)
+	if err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+	span.SetAttributes(attribute.Int64("id", subscription.Id))
+
+	if location := linkTo("webhook", nil, "id", strconv.FormatInt(subscription.Id, 10)); location != "" {
+		w.Header().Set("Location", location)
+	}
+	subscription.Secret = ""
+	respondWithStatus(ctx, http.StatusCreated, subscription, w)
+}
+
+func RouteGetWebhookSubscriptions(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteGetWebhookSubscriptions() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteGetWebhookSubscriptions")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	subscriptions, err := db.DBGetWebhookSubscriptions(ctx, dtb)
+	if err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	respondWithSuccess(ctx, subscriptions, w)
+}
+
+func RouteGetWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteGetWebhookSubscription() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteGetWebhookSubscription")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	span.SetAttributes(attribute.Int64("id", id))
+
+	subscription, err := db.DBGetWebhookSubscription(ctx, dtb, id)
+	if err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+	subscription.Secret = ""
+	respondWithSuccess(ctx, subscription, w)
+}
+
+func RouteDeleteWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteDeleteWebhookSubscription() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteDeleteWebhookSubscription")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	span.SetAttributes(attribute.Int64("id", id))
+
+	if err := db.DBDeleteWebhookSubscription(ctx, dtb, id); err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func RouteGetDeadWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteGetDeadWebhookDeliveries() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteGetDeadWebhookDeliveries")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	start, count, err := listPaging(ctx, r)
+	if err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+
+	deliveries, err := db.DBGetDeadWebhookDeliveries(ctx, dtb, start, count)
+	if err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+	respondWithSuccess(ctx, deliveries, w)
+}
+
+func RouteRetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	log.WithContext(r.Context()).Debugf("RouteRetryWebhookDelivery() called from %s at %s", r.Header.Get("User-Agent"), r.RemoteAddr)
+
+	// Create a child span.
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(r.Context(), "RouteRetryWebhookDelivery")
+	pkg.AddTraceParentToResponse(span, w)
+	defer span.End()
+
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	span.SetAttributes(attribute.Int64("id", id))
+
+	if err := db.DBRetryWebhookDelivery(ctx, dtb, id); err != nil {
+		respondWithError(ctx, err, w)
+		return
+	}
+	if webhookDispatcher != nil {
+		webhookDispatcher.Notify()
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// decodeWebhookSubscription reads and validates the subscription in the request body.
+func decodeWebhookSubscription(r *http.Request) (db.WebhookSubscription, error) {
+	var subscription db.WebhookSubscription
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&subscription); err != nil {
+		return subscription, fmt.Errorf("invalid subscription: %v", err)
+	}
+
+	if err := webhook.CheckURL(subscription.URL); err != nil {
+		return subscription, fmt.Errorf("invalid subscription: %v", err)
+	}
+	if len(subscription.EventTypes) == 0 {
+		return subscription, fmt.Errorf("invalid subscription: event_types is required")
+	}
+	seen := make(map[string]bool, len(subscription.EventTypes))
+	for _, eventType := range subscription.EventTypes {
+		switch eventType {
+		case db.ChangeCreated, db.ChangeUpdated, db.ChangeDeleted:
+		default:
+			return subscription, fmt.Errorf("invalid subscription: unknown event type %q", eventType)
+		}
+		if seen[eventType] {
+			return subscription, fmt.Errorf("invalid subscription: event type %q is given twice", eventType)
+		}
+		seen[eventType] = true
+	}
+	if len(strings.Join(subscription.EventTypes, ",")) > maxEventTypesLength {
+		return subscription, fmt.Errorf("invalid subscription: event_types must be at most %d characters joined", maxEventTypesLength)
+	}
+	if len(subscription.Secret) < minWebhookSecretLength {
+		return subscription, fmt.Errorf("invalid subscription: secret must be at least %d characters", minWebhookSecretLength)
+	}
+	return subscription, nil
+}
@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"time"
+)
+
+// ConfigureLogging sets the level (panic, fatal, error, warn, info, debug or trace) and the format (json or text) of
+// the standard logger.
+func ConfigureLogging(level string, format string) error {
+	logLevel, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{
+			FieldMap: log.FieldMap{
+				log.FieldKeyTime:  "timestamp",
+				log.FieldKeyLevel: "severity",
+				log.FieldKeyMsg:   "message",
+			},
+			TimestampFormat: time.RFC3339Nano,
+		})
+	case "text":
+		log.SetFormatter(&log.TextFormatter{
+			FullTimestamp:   true,
+			TimestampFormat: time.RFC3339Nano,
+		})
+	default:
+		return fmt.Errorf("unsupported log format %q, use json or text", format)
+	}
+
+	log.SetLevel(logLevel)
+	return nil
+}
+
+// TraceHook adds the trace_id and span_id of the span in an entry's context to the entry, so that every line logged
+// with log.WithContext(ctx) during a request can be looked up next to its trace.
+type TraceHook struct{}
+
+func (TraceHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (TraceHook) Fire(entry *log.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	entry.Data["trace_id"] = spanContext.TraceID().String()
+	entry.Data["span_id"] = spanContext.SpanID().String()
+	return nil
+}
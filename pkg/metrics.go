@@ -0,0 +1,172 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/sdkapi"
+	"go.opentelemetry.io/otel/metric/unit"
+	export "go.opentelemetry.io/otel/sdk/export/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregator/histogram"
+	controller "go.opentelemetry.io/otel/sdk/metric/controller/basic"
+	processor "go.opentelemetry.io/otel/sdk/metric/processor/basic"
+	"go.opentelemetry.io/otel/sdk/metric/selector/simple"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Histogram bucket boundaries.  Durations are recorded in milliseconds, every other histogram counts something.
+var (
+	durationBoundaries = []float64{1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+	countBoundaries    = []float64{0, 1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000}
+)
+
+// InitMetrics configures the global meter provider with the exporters named by OTEL_METRICS_EXPORTER, a comma
+// separated list of:
+//
+//	prometheus  The returned handler serves the metrics for Prometheus to scrape.
+//	otlp        Metrics are pushed with OTLP every OTEL_METRIC_EXPORT_INTERVAL milliseconds (60000 by default), over
+//	            gRPC or over HTTP, chosen like for traces.  The exporter reads the other OTEL_EXPORTER_OTLP_* variables.
+//	none        Metrics are not exported.
+//
+// When OTEL_METRICS_EXPORTER is unset metrics are served for Prometheus, and pushed with OTLP as well if an OTLP
+// endpoint is set.  The handler is nil unless prometheus is selected.  The returned function pushes the metrics one
+// last time and shuts the OTLP exporter down.
+func InitMetrics(serviceName string, environmentName string) (http.Handler, func(context.Context) error) {
+	var usePrometheus, useOTLP bool
+	names := strings.TrimSpace(os.Getenv("OTEL_METRICS_EXPORTER"))
+	if names == "" {
+		names = "prometheus"
+		if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT") != "" {
+			names += ",otlp"
+		}
+	}
+	for _, name := range strings.Split(names, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "prometheus":
+			usePrometheus = true
+		case "otlp":
+			useOTLP = true
+		case "none":
+		default:
+			handleErr(fmt.Errorf("unsupported OTEL_METRICS_EXPORTER %q, use prometheus, otlp or none", name), "failed to configure metrics")
+		}
+	}
+	if !usePrometheus && !useOTLP {
+		log.Printf("Metrics are not exported, set OTEL_METRICS_EXPORTER to export them")
+		return nil, func(context.Context) error { return nil }
+	}
+
+	// A pull controller: every scrape and every push collects the current cumulative values.  A collect period of zero
+	// makes sure a scrape never sees the values of an earlier one.
+	ctrl := controller.New(
+		processor.NewFactory(histogramSelector{simple.NewWithInexpensiveDistribution()}, export.CumulativeExportKindSelector()),
+		controller.WithResource(newResource(serviceName, environmentName)),
+		controller.WithCollectPeriod(0),
+	)
+	global.SetMeterProvider(ctrl)
+
+	var handler http.Handler
+	if usePrometheus {
+		exporter, err := prometheus.New(prometheus.Config{DefaultHistogramBoundaries: durationBoundaries}, ctrl)
+		handleErr(err, "failed to create the Prometheus exporter")
+		handler = exporter
+		log.Printf("Serving metrics for Prometheus")
+	}
+
+	if !useOTLP {
+		return handler, func(context.Context) error { return nil }
+	}
+
+	exporter, err := newMetricExporter(context.Background())
+	handleErr(err, "failed to create the metric exporter")
+
+	interval := 60 * time.Second
+	if ms, exists := lookupEnvInt("OTEL_METRIC_EXPORT_INTERVAL"); exists == true {
+		interval = time.Duration(ms) * time.Millisecond
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			if err := pushMetrics(context.Background(), ctrl, exporter); err != nil {
+				log.Warnf("Failed to export metrics: %v", err)
+			}
+		}
+	}()
+
+	return handler, func(ctx context.Context) error {
+		close(stop)
+		<-stopped
+		if err := pushMetrics(ctx, ctrl, exporter); err != nil {
+			exporter.Shutdown(ctx)
+			return err
+		}
+		return exporter.Shutdown(ctx)
+	}
+}
+
+// newMetricExporter returns an OTLP metric exporter using the protocol in OTEL_EXPORTER_OTLP_METRICS_PROTOCOL or
+// OTEL_EXPORTER_OTLP_PROTOCOL.
+func newMetricExporter(ctx context.Context) (*otlpmetric.Exporter, error) {
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_METRICS_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+	switch protocol {
+	case "", "grpc":
+		log.Printf("Exporting metrics with OTLP over gRPC")
+		return otlpmetricgrpc.New(ctx)
+	case "http/protobuf":
+		log.Printf("Exporting metrics with OTLP over HTTP")
+		return otlpmetrichttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q, use grpc or http/protobuf", protocol)
+	}
+}
+
+func pushMetrics(ctx context.Context, ctrl *controller.Controller, exporter *otlpmetric.Exporter) error {
+	if err := ctrl.Collect(ctx); err != nil {
+		return err
+	}
+	return exporter.Export(ctx, ctrl.Resource(), ctrl)
+}
+
+// histogramSelector aggregates histograms with the bucket boundaries that suit their unit, and leaves every other
+// instrument to the selector it wraps.
+type histogramSelector struct {
+	export.AggregatorSelector
+}
+
+func (s histogramSelector) AggregatorFor(descriptor *metric.Descriptor, aggPtrs ...*export.Aggregator) {
+	if descriptor.InstrumentKind() != sdkapi.HistogramInstrumentKind {
+		s.AggregatorSelector.AggregatorFor(descriptor, aggPtrs...)
+		return
+	}
+
+	boundaries := countBoundaries
+	if descriptor.Unit() == unit.Milliseconds {
+		boundaries = durationBoundaries
+	}
+	aggs := histogram.New(len(aggPtrs), descriptor, histogram.WithExplicitBoundaries(boundaries))
+	for i := range aggPtrs {
+		*aggPtrs[i] = &aggs[i]
+	}
+}
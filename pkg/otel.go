@@ -7,56 +7,109 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"os"
 )
 
-// InitOTEL Initializes a Jaeger exporter, and configures the corresponding trace and metric providers.
+// InitOTEL Initializes the trace exporter selected by the environment, see newTraceExporter, and configures the
+// corresponding trace provider.
 func InitOTEL(serviceName string, environmentName string) func(context.Context) error {
 	os.Setenv("OTEL_LOG_LEVEL", "debug")
 
-	const useSplunk = true
-	var collectorUrl string
+	exporter, err := newTraceExporter(context.Background())
+	handleErr(err, "failed to create the trace exporter")
 
-	// Initialize Jaeger Exporter
-	var collectorEndpointOptions []jaeger.CollectorEndpointOption
+	// Resources that will be attached to our Trace Provider
+	res := newResource(serviceName, environmentName)
 
-	if useSplunk == true {
-		// Send Traces directly to Splunk Observability Cloud for ingestion.
-		// To do this, we need to use the correct ingest URL (see below).
-		// Additionally, we need to pass our Splunk Realm and Access Token as well.  These are read from the
-		// SPLUNK_REALM and SPLUNK_ACCESS_TOKEN environment variables respectively.
+	sampler, err := newSampler()
+	handleErr(err, "failed to create the sampler")
 
-		var accessToken = os.Getenv("SPLUNK_ACCESS_TOKEN")
-		if accessToken == "" {
-			log.Fatalf("SPLUNK_ACCESS_TOKEN environment variable not set")
-		}
+	var providerOptions = []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(res),
+	}
+	if processor := newBaggageSpanProcessor(); processor != nil {
+		providerOptions = append(providerOptions, sdktrace.WithSpanProcessor(processor))
+	}
+	if exporter != nil {
+		// Spans are exported in batches in the background, never while a request waits.
+		bsp := sdktrace.NewBatchSpanProcessor(exporter, batchSpanProcessorOptions()...)
+		providerOptions = append(providerOptions, sdktrace.WithSpanProcessor(bsp))
+	}
+	// Without an exporter spans are still created, so trace ids are still propagated and reported to clients.
+	tracerProvider := sdktrace.NewTracerProvider(providerOptions...)
 
-		var realm = os.Getenv("SPLUNK_REALM")
-		if realm == "" {
-			log.Fatalf("SPLUNK_REALM environment variable not set")
-		}
+	// Set the Tracer Provider and the propagators selected by OTEL_PROPAGATORS as globals
+	otel.SetTracerProvider(tracerProvider)
 
-		collectorUrl = fmt.Sprintf("https://ingest.%s.signalfx.com/v2/trace", realm)
-		collectorEndpointOptions = append(collectorEndpointOptions, jaeger.WithUsername("auth"), jaeger.WithPassword(accessToken))
-	} else {
-		// Send Traces to a local OpenTelemetry Collector instance.  For this, we just need to specify the URL.
-		collectorUrl = "http://octodev02:14268/api/traces"
+	propagator, err := newPropagator()
+	handleErr(err, "failed to create the propagators")
+	otel.SetTextMapPropagator(propagator)
+
+	// Register our TracerProvider as the global so any imported
+	// instrumentation in the future will default to using it.
+	otel.SetTracerProvider(tracerProvider)
+
+	if exporter == nil {
+		// Nothing to flush, and the provider fails to shut down without span processors.
+		return func(context.Context) error { return nil }
 	}
+	// Shutting down the provider flushes the spans that have not been exported yet.
+	return tracerProvider.Shutdown
+}
 
-	collectorEndpointOptions = append(collectorEndpointOptions, jaeger.WithEndpoint(collectorUrl))
+// batchSpanProcessorOptions reads the standard OTEL_BSP_* variables, the SDK does not:
+//
+//	OTEL_BSP_MAX_QUEUE_SIZE         Spans buffered for export, further spans are dropped (default 2048).
+//	OTEL_BSP_MAX_EXPORT_BATCH_SIZE  Spans sent per export (default 512).
+//	OTEL_BSP_SCHEDULE_DELAY         Milliseconds between exports (default 5000).
+//	OTEL_BSP_EXPORT_TIMEOUT         Milliseconds an export may take (default 30000).
+func batchSpanProcessorOptions() []sdktrace.BatchSpanProcessorOption {
+	var options []sdktrace.BatchSpanProcessorOption
+	if val, exists := lookupEnvInt("OTEL_BSP_MAX_QUEUE_SIZE"); exists == true {
+		options = append(options, sdktrace.WithMaxQueueSize(val))
+	}
+	if val, exists := lookupEnvInt("OTEL_BSP_MAX_EXPORT_BATCH_SIZE"); exists == true {
+		options = append(options, sdktrace.WithMaxExportBatchSize(val))
+	}
+	if val, exists := lookupEnvInt("OTEL_BSP_SCHEDULE_DELAY"); exists == true {
+		options = append(options, sdktrace.WithBatchTimeout(time.Duration(val)*time.Millisecond))
+	}
+	if val, exists := lookupEnvInt("OTEL_BSP_EXPORT_TIMEOUT"); exists == true {
+		options = append(options, sdktrace.WithExportTimeout(time.Duration(val)*time.Millisecond))
+	}
+	return options
+}
 
-	var endpointOption = jaeger.WithCollectorEndpoint(collectorEndpointOptions...)
-	jaegerExporter, err := jaeger.New(endpointOption)
-	handleErr(err, "failed to create jaegerExporter")
+// lookupEnvInt returns the positive integer in the environment variable name.  Other values are ignored with a
+// warning.
+func lookupEnvInt(name string) (int, bool) {
+	val, exists := os.LookupEnv(name)
+	if exists == false {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || n <= 0 {
+		log.Warnf("Ignoring %s %q, it must be a positive integer", name, val)
+		return 0, false
+	}
+	return n, true
+}
 
-	// Resources that will be attached to our Trace Provider
+// newResource describes this service to the trace and metric backends.
+func newResource(serviceName string, environmentName string) *resource.Resource {
 	res, err := resource.New(context.Background(),
 		resource.WithAttributes(
 			// the service name used to display traces in backends
@@ -66,50 +119,100 @@ func InitOTEL(serviceName string, environmentName string) func(context.Context)
 		),
 	)
 	handleErr(err, "failed to create resource")
+	return res
+}
 
-	bsp := sdktrace.NewBatchSpanProcessor(jaegerExporter)
-	tracerProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(bsp),
-		sdktrace.WithSyncer(jaegerExporter),
-	)
+// newTraceExporter returns the exporter named by OTEL_TRACES_EXPORTER, or nil for "none":
+//
+//	otlp     OTLP over gRPC, or over HTTP if OTEL_EXPORTER_OTLP_TRACES_PROTOCOL or OTEL_EXPORTER_OTLP_PROTOCOL is
+//	         "http/protobuf".  The exporter reads the other OTEL_EXPORTER_OTLP_* variables itself.
+//	jaeger   Jaeger over HTTP, configured by the OTEL_EXPORTER_JAEGER_* variables.  Without an endpoint traces are
+//	         sent to Splunk Observability Cloud if SPLUNK_REALM and SPLUNK_ACCESS_TOKEN are set.
+//	console  Pretty printed JSON on stdout ("stdout" and "logging" are accepted too).
+//	none     Traces are not exported.
+//
+// When OTEL_TRACES_EXPORTER is unset the choice follows from what is configured: jaeger if the Splunk credentials are
+// set, otlp if an OTLP endpoint is set, and none otherwise, so the service runs locally without any setup.
+func newTraceExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("OTEL_TRACES_EXPORTER")))
+	if name == "" {
+		switch {
+		case os.Getenv("SPLUNK_ACCESS_TOKEN") != "" && os.Getenv("SPLUNK_REALM") != "":
+			name = "jaeger"
+		case os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "":
+			name = "otlp"
+		default:
+			name = "none"
+		}
+	}
 
-	// Set the Tracer Provider and the W3C Trace Context propagator as globals
-	otel.SetTracerProvider(tracerProvider)
+	switch name {
+	case "otlp":
+		protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+		if protocol == "" {
+			protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+		}
+		switch protocol {
+		case "", "grpc":
+			log.Printf("Exporting traces with OTLP over gRPC")
+			return otlptracegrpc.New(ctx)
+		case "http/protobuf":
+			log.Printf("Exporting traces with OTLP over HTTP")
+			return otlptracehttp.New(ctx)
+		default:
+			return nil, fmt.Errorf("unsupported OTLP protocol %q, use grpc or http/protobuf", protocol)
+		}
+	case "jaeger":
+		return newJaegerExporter()
+	case "console", "stdout", "logging":
+		log.Printf("Exporting traces to stdout")
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "none":
+		log.Printf("Traces are not exported, set OTEL_TRACES_EXPORTER to export them")
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q, use otlp, jaeger, console or none", name)
+	}
+}
 
-	otel.SetTextMapPropagator(
-		propagation.NewCompositeTextMapPropagator(
-			//jaegerPropagator.Jaeger{},
-			//propagation.Baggage{},
-			propagation.TraceContext{}),
-	)
+func newJaegerExporter() (sdktrace.SpanExporter, error) {
+	var collectorEndpointOptions []jaeger.CollectorEndpointOption
 
-	// Register our TracerProvider as the global so any imported
-	// instrumentation in the future will default to using it.
-	otel.SetTracerProvider(tracerProvider)
+	var accessToken = os.Getenv("SPLUNK_ACCESS_TOKEN")
+	var realm = os.Getenv("SPLUNK_REALM")
+	if os.Getenv("OTEL_EXPORTER_JAEGER_ENDPOINT") == "" && accessToken != "" && realm != "" {
+		// Send Traces directly to Splunk Observability Cloud for ingestion.
+		// To do this, we need to use the correct ingest URL for the realm and authenticate with the access token.
+		var collectorUrl = fmt.Sprintf("https://ingest.%s.signalfx.com/v2/trace", realm)
+		collectorEndpointOptions = append(collectorEndpointOptions,
+			jaeger.WithEndpoint(collectorUrl), jaeger.WithUsername("auth"), jaeger.WithPassword(accessToken))
+		log.Printf("Exporting traces to Splunk Observability Cloud in realm %s", realm)
+	} else {
+		// The exporter falls back to OTEL_EXPORTER_JAEGER_ENDPOINT, USER and PASSWORD, or a local collector.
+		log.Printf("Exporting traces with Jaeger")
+	}
 
-	return jaegerExporter.Shutdown
+	return jaeger.New(jaeger.WithCollectorEndpoint(collectorEndpointOptions...))
 }
 
 // AddTraceParentToResponse Adds the required HTTP Headers to the HTTP Response such that trace context is propagated
 // between services.  This is only necessary for the parent-most Span in a Trace.  All child spans will utilize
 // the same HTTP Response so it's not necessary to call for every child span.
+//
+// The trace is reported twice: as a traceparent entry of Server-Timing, which browsers expose to the Resource Timing
+// API, and as the traceresponse header of W3C Trace Context Level 2.  Both carry the real sampling decision, so RUM
+// tools can tell whether the trace of a request was kept.  Scripts on other origins can only read them when the CORS
+// configuration exposes them, as it does by default.
 func AddTraceParentToResponse(span trace.Span, w http.ResponseWriter) {
-	traceParent, _ := formatAsTraceParent(span.SpanContext())
-	fmt.Printf("traceparent: %s\n", traceParent)
-	w.Header().Add("Access-Control-Expose-Headers", "Server-Timing")
-	w.Header().Add("Server-Timing", traceParent)
+	traceParent := formatAsTraceParent(span.SpanContext())
+	w.Header().Add("Server-Timing", fmt.Sprintf("traceparent;desc=\"%s\"", traceParent))
+	w.Header().Set("traceresponse", traceParent)
 }
 
-// Converts the Trace ID and Span ID in the supplied context into a `traceparent` entry in the standard W3C format.
+// Converts the Trace ID, Span ID and trace flags in the supplied context into the standard W3C `traceparent` format.
 // See https://www.w3.org/TR/trace-context/#version-format for specific formatting.
-func formatAsTraceParent(ctx trace.SpanContext) (string, bool) {
-	traceID := ctx.TraceID()
-	spanID := ctx.SpanID()
-
-	answer := fmt.Sprintf("traceparent;desc=\"00-%s-%s-01\"", traceID, spanID)
-	return answer, true
+func formatAsTraceParent(ctx trace.SpanContext) string {
+	return fmt.Sprintf("00-%s-%s-%s", ctx.TraceID(), ctx.SpanID(), ctx.TraceFlags())
 }
 
 func handleErr(err error, message string) {
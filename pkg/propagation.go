@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"os"
+	"strings"
+)
+
+// newPropagator returns the propagators named in OTEL_PROPAGATORS, a comma separated list of:
+//
+//	tracecontext  W3C traceparent and tracestate headers.
+//	baggage       W3C baggage header.
+//	b3            Zipkin B3 single header.
+//	b3multi       Zipkin B3 X-B3-* headers.
+//	jaeger        Jaeger uber-trace-id header.
+//	none          Nothing is propagated.
+//
+// Incoming requests may use any of them, outgoing requests carry all of them.  The default is tracecontext,baggage.
+func newPropagator() (propagation.TextMapPropagator, error) {
+	names := strings.TrimSpace(os.Getenv("OTEL_PROPAGATORS"))
+	if names == "" {
+		names = "tracecontext,baggage"
+	}
+
+	var propagators []propagation.TextMapPropagator
+	for _, name := range strings.Split(names, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "tracecontext":
+			propagators = append(propagators, propagation.TraceContext{})
+		case "baggage":
+			propagators = append(propagators, propagation.Baggage{})
+		case "b3":
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case "b3multi":
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case "jaeger":
+			propagators = append(propagators, jaeger.Jaeger{})
+		case "none", "":
+		default:
+			return nil, fmt.Errorf("unsupported OTEL_PROPAGATORS entry %q, use tracecontext, baggage, b3, b3multi, jaeger or none", name)
+		}
+	}
+
+	log.Printf("Propagating trace context with %s", names)
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
+
+// newBaggageSpanProcessor returns a span processor copying the baggage members named in the comma separated
+// BAGGAGE_SPAN_ATTRIBUTES to span attributes, or nil if there are none.
+func newBaggageSpanProcessor() sdktrace.SpanProcessor {
+	var keys []string
+	for _, key := range strings.Split(os.Getenv("BAGGAGE_SPAN_ATTRIBUTES"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return baggageSpanProcessor{keys: keys}
+}
+
+// baggageSpanProcessor sets an attribute named after each of keys on every span started in a context whose baggage
+// has a member with that key.  Every span of a request carries them, not just the server span, so any of them can be
+// found by tenant or client.
+type baggageSpanProcessor struct {
+	keys []string
+}
+
+func (p baggageSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	bag := baggage.FromContext(parent)
+	if bag.Len() == 0 {
+		return
+	}
+	for _, key := range p.keys {
+		if member := bag.Member(key); member.Key() != "" {
+			s.SetAttributes(attribute.String(key, member.Value()))
+		}
+	}
+}
+
+func (p baggageSpanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (p baggageSpanProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (p baggageSpanProcessor) ForceFlush(context.Context) error {
+	return nil
+}
@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Span names that are never sampled unless TRACES_SUPPRESSED_SPANS says otherwise: the server spans of the health
+// checks and probes, which load balancers and Kubernetes call every few seconds, and the webhook outbox poll, which runs every
+// second.  Webhook deliveries have spans of their own.
+const defaultSuppressedSpans = "/api/v1/,/api/v1/health-check,/healthz,/readyz,DBClaimWebhookDeliveries"
+
+// newSampler returns the sampler named by OTEL_TRACES_SAMPLER, taking the sampling ratio from OTEL_TRACES_SAMPLER_ARG:
+//
+//	parentbased_always_on     Sample everything, unless the caller decided not to.  The default.
+//	parentbased_traceidratio  Sample the given ratio of new traces, and follow the caller's decision otherwise.
+//	parentbased_always_off    Sample nothing, unless the caller decided to.
+//	always_on, traceidratio, always_off  The same, ignoring the caller's decision.
+//
+// Spans named in the comma separated TRACES_SUPPRESSED_SPANS are never sampled, whatever the sampler decides.  Their
+// child spans are not sampled either, so suppressing the server span of a route suppresses the whole request.
+func newSampler() (sdktrace.Sampler, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("OTEL_TRACES_SAMPLER")))
+	if name == "" {
+		name = "parentbased_always_on"
+	}
+
+	var sampler sdktrace.Sampler
+	switch name {
+	case "always_on":
+		sampler = sdktrace.AlwaysSample()
+	case "always_off":
+		sampler = sdktrace.NeverSample()
+	case "traceidratio":
+		sampler = sdktrace.TraceIDRatioBased(samplerRatio())
+	case "parentbased_always_on":
+		sampler = sdktrace.ParentBased(sdktrace.AlwaysSample())
+	case "parentbased_always_off":
+		sampler = sdktrace.ParentBased(sdktrace.NeverSample())
+	case "parentbased_traceidratio":
+		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplerRatio()))
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_SAMPLER %q", name)
+	}
+
+	suppressed, exists := os.LookupEnv("TRACES_SUPPRESSED_SPANS")
+	if exists == false {
+		suppressed = defaultSuppressedSpans
+	}
+	names := make(map[string]bool)
+	for _, spanName := range strings.Split(suppressed, ",") {
+		if spanName = strings.TrimSpace(spanName); spanName != "" {
+			names[spanName] = true
+		}
+	}
+	if len(names) > 0 {
+		sampler = suppressingSampler{names: names, sampler: sampler}
+	}
+
+	log.Printf("Sampling traces with %s", sampler.Description())
+	return sampler, nil
+}
+
+// samplerRatio returns OTEL_TRACES_SAMPLER_ARG as a ratio, or 1 if it is unset or invalid.
+func samplerRatio() float64 {
+	val, exists := os.LookupEnv("OTEL_TRACES_SAMPLER_ARG")
+	if exists == false {
+		return 1
+	}
+	ratio, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		log.Warnf("Ignoring OTEL_TRACES_SAMPLER_ARG %q, it must be a ratio between 0 and 1", val)
+		return 1
+	}
+	return ratio
+}
+
+// suppressingSampler drops the spans with the given names and leaves every other decision to sampler.
+type suppressingSampler struct {
+	names   map[string]bool
+	sampler sdktrace.Sampler
+}
+
+func (s suppressingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if s.names[p.Name] {
+		return sdktrace.SamplingResult{
+			Decision:   sdktrace.Drop,
+			Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+		}
+	}
+	return s.sampler.ShouldSample(p)
+}
+
+func (s suppressingSampler) Description() string {
+	return fmt.Sprintf("%s suppressing %d span names", s.sampler.Description(), len(s.names))
+}
@@ -0,0 +1,88 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phases reported in the Server-Timing header.
+const (
+	PhaseDB        = "db"
+	PhaseScan      = "scan"
+	PhaseSerialize = "serialize"
+	PhaseTotal     = "total"
+)
+
+// Timer adds up how long a request spends in each phase, for the Server-Timing response header.  It travels in the
+// request context, see ContextWithTimer, so the database functions can time their part without knowing about HTTP.
+// The methods of a nil *Timer do nothing, so code running outside of a request needs no checks.
+type Timer struct {
+	start time.Time
+
+	mu     sync.Mutex
+	phases []string
+	totals map[string]time.Duration
+}
+
+type timerKey struct{}
+
+// NewTimer returns a Timer whose total runs from now.
+func NewTimer() *Timer {
+	return &Timer{start: time.Now(), totals: make(map[string]time.Duration)}
+}
+
+// ContextWithTimer returns a copy of ctx carrying t.
+func ContextWithTimer(ctx context.Context, t *Timer) context.Context {
+	return context.WithValue(ctx, timerKey{}, t)
+}
+
+// TimerFromContext returns the Timer in ctx, or nil if there is none.
+func TimerFromContext(ctx context.Context) *Timer {
+	t, _ := ctx.Value(timerKey{}).(*Timer)
+	return t
+}
+
+// Start starts timing phase and returns the function that stops it.  A phase timed more than once is reported with
+// the sum of its durations.
+func (t *Timer) Start(phase string) func() {
+	if t == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		t.add(phase, time.Since(start))
+	}
+}
+
+func (t *Timer) add(phase string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, exists := t.totals[phase]; !exists {
+		t.phases = append(t.phases, phase)
+	}
+	t.totals[phase] += d
+}
+
+// ServerTiming formats the phases timed so far, followed by the total time since the Timer was created, as a
+// Server-Timing header value, for example "db;dur=1.204, serialize;dur=0.088, total;dur=1.611".
+func (t *Timer) ServerTiming() string {
+	if t == nil {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	metrics := make([]string, 0, len(t.phases)+1)
+	for _, phase := range t.phases {
+		metrics = append(metrics, formatServerTiming(phase, t.totals[phase]))
+	}
+	metrics = append(metrics, formatServerTiming(PhaseTotal, time.Since(t.start)))
+	return strings.Join(metrics, ", ")
+}
+
+func formatServerTiming(name string, d time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.3f", name, float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for webhook URLs that point at the service's own network, which would let anyone
+// able to subscribe make the dispatcher send requests to internal services or cloud metadata endpoints.
+var ErrForbiddenAddress = errors.New("webhook URLs must not point at loopback, private or link-local addresses")
+
+// Networks no delivery is made to.
+var forbiddenNetworks = parseCIDRs(
+	"0.0.0.0/8",      // This network
+	"10.0.0.0/8",     // Private
+	"100.64.0.0/10",  // Carrier-grade NAT
+	"127.0.0.0/8",    // Loopback
+	"169.254.0.0/16", // Link-local, including cloud metadata endpoints
+	"172.16.0.0/12",  // Private
+	"192.168.0.0/16", // Private
+	"224.0.0.0/4",    // Multicast
+	"::/128",         // Unspecified
+	"::1/128",        // Loopback
+	"fc00::/7",       // Unique local
+	"fe80::/10",      // Link-local
+	"ff00::/8",       // Multicast
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func forbiddenIP(ip net.IP) bool {
+	for _, network := range forbiddenNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckURL checks that rawURL is an absolute http or https URL whose host is not a forbidden address.  Host names are
+// only checked when the dispatcher connects, as they may resolve to other addresses by then.
+func CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && forbiddenIP(ip) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// newHTTPClient returns the client deliveries are made with.  It refuses to connect to forbidden addresses, checking
+// the address every connection is made to after DNS resolution, so that neither a host name resolving to one, nor a
+// redirect, reaches the internal network.  Proxies from the environment are not used, they would connect instead.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network string, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || forbiddenIP(ip) {
+				return fmt.Errorf("connecting to %s: %w", address, ErrForbiddenAddress)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
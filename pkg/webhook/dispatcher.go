@@ -0,0 +1,269 @@
+// Package webhook delivers the entries of the webhook outbox to the subscribed URLs.
+//
+// Every delivery is a POST of the db.NationalParkChange as JSON with these headers:
+//
+//	X-Webhook-Id         the change id, the same for every attempt so receivers can drop duplicates
+//	X-Webhook-Event      created, updated or deleted
+//	X-Webhook-Timestamp  Unix time of the attempt in seconds
+//	X-Webhook-Signature  sha256=<hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the subscription secret>
+//
+// Any 2xx response acknowledges the delivery.  Everything else is retried with exponential backoff until the attempts
+// run out and the delivery becomes a dead letter.  Deliveries are at least once, and retries can reorder them.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"math/rand"
+	"nationalparks-rest/pkg"
+	"nationalparks-rest/pkg/db"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderId        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Dispatcher delivers the outbox.  Several instances can run against the same database, each delivery is leased by
+// one of them at a time.
+type Dispatcher struct {
+	db             *sql.DB
+	httpClient     *http.Client
+	pollInterval   time.Duration
+	batchSize      int
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	wake           chan struct{}
+}
+
+// Option customizes a Dispatcher created by NewDispatcher.
+type Option func(d *Dispatcher)
+
+// WithHTTPClient replaces the http.Client used for deliveries.  Its timeout bounds every attempt.  Unlike the default
+// client it may connect to any address, such as a receiver on localhost in tests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.httpClient = httpClient
+	}
+}
+
+// WithPollInterval sets how often the outbox is checked for due deliveries.
+func WithPollInterval(pollInterval time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.pollInterval = pollInterval
+	}
+}
+
+// WithRetries sets how many attempts a delivery gets before it becomes a dead letter, and the backoff after the first
+// failed attempt.  The backoff doubles with every attempt up to maxBackoff.
+func WithRetries(maxAttempts int, initialBackoff time.Duration, maxBackoff time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.maxAttempts = maxAttempts
+		d.initialBackoff = initialBackoff
+		d.maxBackoff = maxBackoff
+	}
+}
+
+// NewDispatcher returns a Dispatcher delivering the outbox in dtb.
+func NewDispatcher(dtb *sql.DB, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		db:             dtb,
+		httpClient:     newHTTPClient(10 * time.Second),
+		pollInterval:   time.Second,
+		batchSize:      20,
+		maxAttempts:    8,
+		initialBackoff: 5 * time.Second,
+		maxBackoff:     time.Hour,
+		wake:           make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Notify makes Run check the outbox now instead of at the next poll.  It never blocks.
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers the outbox until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+
+		// Keep going while full batches come back, there is more due.
+		for {
+			n, err := d.DispatchPending(ctx)
+			if err != nil {
+				log.Warnf("Failed to dispatch webhooks: %v", err)
+			}
+			if err != nil || n < d.batchSize {
+				break
+			}
+		}
+	}
+}
+
+// DispatchPending attempts one batch of due deliveries and returns how many it attempted.
+func (d *Dispatcher) DispatchPending(ctx context.Context) (int, error) {
+	// The lease must outlast every attempt in the batch, they are made one after the other.
+	lease := time.Duration(d.batchSize)*d.httpClient.Timeout + time.Minute
+	deliveries, err := db.DBClaimWebhookDeliveries(ctx, d.db, d.batchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		attempts := delivery.Attempts + 1
+		if err = d.deliver(ctx, delivery); err == nil {
+			err = db.DBCompleteWebhookDelivery(ctx, d.db, delivery.Id, attempts)
+		} else {
+			dead := attempts >= d.maxAttempts
+			if dead {
+				log.Warnf("Webhook delivery %d to %s failed %d times, moving it to the dead letters: %v", delivery.Id, delivery.URL, attempts, err)
+			}
+			err = db.DBFailWebhookDelivery(ctx, d.db, delivery.Id, attempts, err.Error(), time.Now().Add(d.backoff(attempts)), dead)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// deliver makes a single attempt.
+func (d *Dispatcher) deliver(ctx context.Context, delivery db.WebhookDelivery) error {
+	tracer := otel.GetTracerProvider().Tracer(pkg.TRACER_NAME)
+	ctx, span := tracer.Start(ctx, "DeliverWebhook", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	span.SetAttributes(
+		semconv.HTTPMethodKey.String(http.MethodPost),
+		semconv.HTTPURLKey.String(delivery.URL),
+		attribute.Int64("webhook.delivery", delivery.Id),
+		attribute.Int64("webhook.subscription", delivery.SubscriptionId),
+		attribute.Int("webhook.attempt", delivery.Attempts+1),
+	)
+
+	err := d.post(ctx, delivery)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func (d *Dispatcher) post(ctx context.Context, delivery db.WebhookDelivery) error {
+	body, err := json.Marshal(delivery.Change)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nationalparks-rest-webhooks")
+	req.Header.Set(HeaderId, strconv.FormatInt(delivery.Change.Id, 10))
+	req.Header.Set(HeaderEvent, delivery.Change.Type)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, body))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	trace.SpanFromContext(ctx).SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s responded %s", delivery.URL, resp.Status)
+	}
+	return nil
+}
+
+// backoff returns the delay after failed attempt number attempts: exponential growth capped at maxBackoff, plus up to
+// 10% jitter so that deliveries that failed together are not retried in lockstep.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.initialBackoff << uint(attempts-1)
+	if delay > d.maxBackoff || delay <= 0 {
+		delay = d.maxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/10+1))
+}
+
+// Sign returns the X-Webhook-Signature header value for a body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+var (
+	ErrMissingSignature = errors.New("webhook signature or timestamp missing")
+	ErrInvalidSignature = errors.New("webhook signature does not match")
+	ErrStaleTimestamp   = errors.New("webhook timestamp outside of the tolerance")
+)
+
+// Verify checks the signature of a received delivery, for receivers written in Go.  Deliveries with a timestamp more
+// than tolerance away from now are rejected to limit replays; a tolerance of zero skips that check.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	signature := header.Get(HeaderSignature)
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if signature == "" || err != nil {
+		return ErrMissingSignature
+	}
+	if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		age := time.Since(time.Unix(timestamp, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrStaleTimestamp
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"io"
+	"nationalparks-rest/pkg/db"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef"
+
+// received is what a test receiver got.
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver starts a receiver answering every delivery with status and recording it.
+func newReceiver(t *testing.T, status int) (*httptest.Server, func() []received) {
+	var mu sync.Mutex
+	var deliveries []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		deliveries = append(deliveries, received{header: r.Header.Clone(), body: body})
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []received {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]received(nil), deliveries...)
+	}
+}
+
+// expectClaim expects DispatchPending to claim one delivery of change 42 to url, after attempts earlier attempts.
+func expectClaim(mock sqlmock.Sqlmock, url string, attempts int) {
+	columns := []string{"ID", "SUBSCRIPTION_ID", "URL", "SECRET", "STATUS", "ATTEMPTS", "NEXT_ATTEMPT_AT", "LAST_ERROR",
+		"CHANGE_ID", "EVENT_TYPE", "CREATED_AT", "PAYLOAD"}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .* FROM WEBHOOK_OUTBOX O .* FOR UPDATE OF O SKIP LOCKED").
+		WithArgs(db.DeliveryPending, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(11, 3, url, testSecret, db.DeliveryPending, attempts, time.Now(), "",
+			42, db.ChangeUpdated, time.Now(), `{"id":1,"location_name":"Adams National Historical Park"}`))
+	mock.ExpectExec("UPDATE WEBHOOK_OUTBOX SET NEXT_ATTEMPT_AT = \\? WHERE ID IN \\(\\?\\)").
+		WithArgs(sqlmock.AnyArg(), 11).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+// expectFailure expects the failed attempt number attempts to be recorded with status, and to be tried again within
+// [minDelay, maxDelay].  lastError must contain the error recorded.
+func expectFailure(mock sqlmock.Sqlmock, status string, attempts int, lastError string, minDelay time.Duration, maxDelay time.Duration) {
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE WEBHOOK_OUTBOX SET STATUS = \\?, ATTEMPTS = \\?, LAST_ERROR = \\?, NEXT_ATTEMPT_AT = \\? WHERE ID = \\?").
+		WithArgs(status, attempts, containing(lastError), within(minDelay, maxDelay), 11).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+type containing string
+
+func (c containing) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	return ok && strings.Contains(s, string(c))
+}
+
+type delayMatcher struct{ min, max time.Duration }
+
+func within(min time.Duration, max time.Duration) sqlmock.Argument { return delayMatcher{min, max} }
+
+func (m delayMatcher) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	if !ok {
+		return false
+	}
+	delay := time.Until(t)
+	// Allow for the time the test takes between the attempt and the check.
+	return delay >= m.min-time.Second && delay <= m.max
+}
+
+func newMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	dtb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dtb.Close() })
+	return dtb, mock
+}
+
+func TestDispatchDeliversSignedChange(t *testing.T) {
+	server, deliveries := newReceiver(t, http.StatusNoContent)
+	dtb, mock := newMock(t)
+	expectClaim(mock, server.URL, 0)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE WEBHOOK_OUTBOX SET STATUS = \\?, ATTEMPTS = \\?, LAST_ERROR = NULL, DELIVERED_AT = \\? WHERE ID = \\?").
+		WithArgs(db.DeliveryDelivered, 1, sqlmock.AnyArg(), 11).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	d := NewDispatcher(dtb, WithHTTPClient(server.Client()))
+	if n, err := d.DispatchPending(context.Background()); n != 1 || err != nil {
+		t.Fatalf("DispatchPending() = %d, %v, want 1 delivery", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	got := deliveries()
+	if len(got) != 1 {
+		t.Fatalf("the receiver got %d deliveries, want 1", len(got))
+	}
+	delivery := got[0]
+	if err := Verify(testSecret, delivery.header, delivery.body, time.Minute); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+	if err := Verify("another-secret-entirely", delivery.header, delivery.body, time.Minute); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() with another secret = %v, want %v", err, ErrInvalidSignature)
+	}
+	if id := delivery.header.Get(HeaderId); id != "42" {
+		t.Errorf("%s = %q, want 42", HeaderId, id)
+	}
+	if event := delivery.header.Get(HeaderEvent); event != db.ChangeUpdated {
+		t.Errorf("%s = %q, want %s", HeaderEvent, event, db.ChangeUpdated)
+	}
+	if !strings.Contains(string(delivery.body), "Adams National Historical Park") {
+		t.Errorf("the body %s does not carry the park", delivery.body)
+	}
+}
+
+func TestDispatchRetriesWithBackoffThenDeadLetters(t *testing.T) {
+	server, deliveries := newReceiver(t, http.StatusInternalServerError)
+	dtb, mock := newMock(t)
+	// Backoff doubles from a minute, plus up to 10% jitter, and the third failure is the last attempt.
+	expectClaim(mock, server.URL, 0)
+	expectFailure(mock, db.DeliveryPending, 1, "500 Internal Server Error", time.Minute, 66*time.Second)
+	expectClaim(mock, server.URL, 1)
+	expectFailure(mock, db.DeliveryPending, 2, "500 Internal Server Error", 2*time.Minute, 132*time.Second)
+	expectClaim(mock, server.URL, 2)
+	expectFailure(mock, db.DeliveryDead, 3, "500 Internal Server Error", 0, time.Hour)
+
+	d := NewDispatcher(dtb, WithHTTPClient(server.Client()), WithRetries(3, time.Minute, time.Hour))
+	for i := 0; i < 3; i++ {
+		if n, err := d.DispatchPending(context.Background()); n != 1 || err != nil {
+			t.Fatalf("attempt %d: DispatchPending() = %d, %v, want 1 delivery", i+1, n, err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if got := len(deliveries()); got != 3 {
+		t.Errorf("the receiver got %d deliveries, want 3", got)
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	d := NewDispatcher(nil, WithRetries(10, time.Second, 5*time.Second))
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 60: 5 * time.Second} {
+		if got := d.backoff(attempts); got < want || got > want+want/10 {
+			t.Errorf("backoff(%d) = %s, want %s plus up to 10%%", attempts, got, want)
+		}
+	}
+}
+
+func TestDefaultClientRefusesPrivateAddresses(t *testing.T) {
+	// The receiver listens on the loopback interface, which the default client must not connect to.
+	server, deliveries := newReceiver(t, http.StatusNoContent)
+	dtb, mock := newMock(t)
+	expectClaim(mock, server.URL, 0)
+	expectFailure(mock, db.DeliveryPending, 1, ErrForbiddenAddress.Error(), time.Minute, 66*time.Second)
+
+	d := NewDispatcher(dtb, WithRetries(3, time.Minute, time.Hour))
+	if _, err := d.DispatchPending(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if got := len(deliveries()); got != 0 {
+		t.Errorf("the receiver got %d deliveries, want none", got)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	for url, allowed := range map[string]bool{
+		"https://partner.example.com/parks":        true,
+		"http://93.184.216.34:8080/hook":           true,
+		"ftp://partner.example.com/parks":          false,
+		"/parks":                                   false,
+		"http://localhost:8080/hook":               false,
+		"http://api.localhost/hook":                false,
+		"http://127.0.0.1/hook":                    false,
+		"http://10.1.2.3/hook":                     false,
+		"http://172.20.0.1/hook":                   false,
+		"http://192.168.3.230/hook":                false,
+		"http://169.254.169.254/latest/meta-data/": false,
+		"http://[::1]/hook":                        false,
+		"http://[fd00::1]/hook":                    false,
+		"http://[::ffff:127.0.0.1]/hook":           false,
+		"http://0.0.0.0/hook":                      false,
+	} {
+		if err := CheckURL(url); (err == nil) != allowed {
+			t.Errorf("CheckURL(%q) = %v, want allowed %v", url, err, allowed)
+		}
+	}
+}
@@ -3,12 +3,61 @@
 source config.sh
 
 # See config.sh for setting these values.
-docker run -it -p 8080:8080 \
+docker run -it -p 8080:8080 -p 9090:9090 \
 	-e SPLUNK_ACCESS_TOKEN=${SPLUNK_ACCESS_TOKEN} \
 	-e SPLUNK_REALM=${SPLUNK_REALM} \
+	-e OTEL_TRACES_EXPORTER=${OTEL_TRACES_EXPORTER} \
+	-e OTEL_EXPORTER_OTLP_ENDPOINT=${OTEL_EXPORTER_OTLP_ENDPOINT} \
+	-e OTEL_EXPORTER_OTLP_PROTOCOL=${OTEL_EXPORTER_OTLP_PROTOCOL} \
+	-e OTEL_METRICS_EXPORTER=${OTEL_METRICS_EXPORTER} \
+	-e OTEL_TRACES_SAMPLER=${OTEL_TRACES_SAMPLER} \
+	-e OTEL_TRACES_SAMPLER_ARG=${OTEL_TRACES_SAMPLER_ARG} \
+	-e OTEL_PROPAGATORS=${OTEL_PROPAGATORS} \
+	-e BAGGAGE_SPAN_ATTRIBUTES=${BAGGAGE_SPAN_ATTRIBUTES} \
+	-e CONFIG_FILE=${CONFIG_FILE} \
 	-e DBHOST=${DBHOST} \
 	-e DBPORT=${DBPORT} \
+	-e DBUSER=${DBUSER} \
+	-e DBPASSWORD=${DBPASSWORD} \
+	-e DBNAME=${DBNAME} \
+	-e DBUSER_FILE=${DBUSER_FILE} \
+	-e DBPASSWORD_FILE=${DBPASSWORD_FILE} \
+	-e DBPARAMS=${DBPARAMS} \
 	-e HTTPHOST=${HTTPHOST} \
 	-e HTTPPORT=${HTTPPORT} \
+	-e GRPCPORT=${GRPCPORT} \
+	-e TRUSTED_PROXIES=${TRUSTED_PROXIES} \
+	-e TLS_CERT_FILE=${TLS_CERT_FILE} \
+	-e TLS_KEY_FILE=${TLS_KEY_FILE} \
+	-e TLS_MIN_VERSION=${TLS_MIN_VERSION} \
+	-e TLS_CLIENT_CA_FILE=${TLS_CLIENT_CA_FILE} \
+	-e TLS_CLIENT_AUTH=${TLS_CLIENT_AUTH} \
+	-e H2C=${H2C} \
+	-e CORS_ALLOWED_ORIGINS=${CORS_ALLOWED_ORIGINS} \
+	-e CORS_ALLOWED_METHODS=${CORS_ALLOWED_METHODS} \
+	-e CORS_ALLOWED_HEADERS=${CORS_ALLOWED_HEADERS} \
+	-e CORS_EXPOSED_HEADERS=${CORS_EXPOSED_HEADERS} \
+	-e CORS_ALLOW_CREDENTIALS=${CORS_ALLOW_CREDENTIALS} \
+	-e CORS_MAX_AGE=${CORS_MAX_AGE} \
+	-e LOG_LEVEL=${LOG_LEVEL} \
+	-e LOG_FORMAT=${LOG_FORMAT} \
+	-e ADMIN_TOKEN=${ADMIN_TOKEN} \
+	-e WRITE_TOKEN=${WRITE_TOKEN} \
+	-e WEBHOOK_TOKEN=${WEBHOOK_TOKEN} \
+	-e DB_CONNECT_TIMEOUT=${DB_CONNECT_TIMEOUT} \
+	-e DB_MAX_OPEN_CONNS=${DB_MAX_OPEN_CONNS} \
+	-e DB_MAX_IDLE_CONNS=${DB_MAX_IDLE_CONNS} \
+	-e DB_CONN_MAX_LIFETIME=${DB_CONN_MAX_LIFETIME} \
+	-e DB_CONN_MAX_IDLE_TIME=${DB_CONN_MAX_IDLE_TIME} \
+	-e DB_QUERY_TIMEOUT=${DB_QUERY_TIMEOUT} \
+	-e DB_READ_RETRIES=${DB_READ_RETRIES} \
+	-e DB_BREAKER_FAILURES=${DB_BREAKER_FAILURES} \
+	-e DB_BREAKER_COOLDOWN=${DB_BREAKER_COOLDOWN} \
+	-e DBREPLICAS=${DBREPLICAS} \
+	-e DB_REPLICA_POLICY=${DB_REPLICA_POLICY} \
+	-e DB_REPLICA_CHECK_INTERVAL=${DB_REPLICA_CHECK_INTERVAL} \
+	-e DB_PRIMARY_AFTER_WRITE=${DB_PRIMARY_AFTER_WRITE} \
+	-e SHUTDOWN_DELAY=${SHUTDOWN_DELAY} \
+	-e SHUTDOWN_TIMEOUT=${SHUTDOWN_TIMEOUT} \
 	nationalparks-rest
 
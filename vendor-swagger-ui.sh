@@ -0,0 +1,18 @@
+#!/bin/bash
+
+# Vendors the swagger-ui-dist assets the docs page at /api/v1/docs loads into pkg/http/openapi/swagger-ui, where they
+# are embedded into the binary, so that the page works without access to the internet.  The version is read from the
+# VERSION file there; change it and run this script again to upgrade, then commit the files.
+
+set -euo pipefail
+
+DIR="$(cd "$(dirname "$0")" && pwd)/pkg/http/openapi/swagger-ui"
+VERSION=$(cat "${DIR}/VERSION")
+TMP=$(mktemp -d)
+trap 'rm -rf "${TMP}"' EXIT
+
+curl -fsSL "https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-${VERSION}.tgz" | tar -xz -C "${TMP}"
+for FILE in swagger-ui.css swagger-ui-bundle.js LICENSE; do
+  cp "${TMP}/package/${FILE}" "${DIR}/${FILE}"
+done
+echo "Vendored swagger-ui-dist ${VERSION} into ${DIR}"
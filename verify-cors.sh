@@ -2,5 +2,7 @@
 
 source config.sh
 
-curl --include -H 'Origin: http://foo.com' ${BACKEND_URL}/api/v1/health-check $*
+# The origin to call from, which must be in the service's CORS_ALLOWED_ORIGINS to get CORS headers back.
+ORIGIN=${ORIGIN:-http://foo.com}
 
+curl --include -H "Origin: ${ORIGIN}" ${BACKEND_URL}/api/v1/health-check $*